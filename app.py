@@ -15,13 +15,25 @@ import sys
 import threading
 import time
 
-from flask import Flask, jsonify, request, send_from_directory
+from flask import Flask, Response, jsonify, request, send_from_directory
 from flask_cors import CORS
 from flask_socketio import SocketIO
 
 from config import ConfigStore, MAX_SHAIRPORT_LATENCY_OFFSET
+from metrics import METRICS_CONTENT_TYPE, render_metrics
+from system_checks import run_checks
 from tts_webrtc import TtsWebRtcService
-from zone import ZoneManager
+from zone import (
+    DEFAULT_START_STAGGER_SECONDS,
+    MAX_START_STAGGER_SECONDS,
+    DuplicateZoneNameError,
+    InvalidZoneNameError,
+    ShairportArgsError,
+    SpeakerGroupError,
+    StaticAddressError,
+    ZoneManager,
+    normalize_speaker_groups,
+)
 
 # ---------------------------------------------------------------------------
 # Logging
@@ -144,9 +156,20 @@ def _public_settings(settings=None):
     settings = settings or _settings()
     return {
         "default_interface": settings.get("default_interface", ""),
+        "start_stagger_seconds": settings.get("start_stagger_seconds", DEFAULT_START_STAGGER_SECONDS),
+        "interface_allowlist": settings.get("interface_allowlist", []),
+        "speaker_groups": settings.get("speaker_groups", []),
     }
 
 
+def _cover_art_url(zone, now_playing):
+    if not now_playing or not now_playing.get("has_cover_art"):
+        return None
+    # The version changes with each update so clients refetch new art.
+    version = int((now_playing.get("updated_at") or 0) * 1000)
+    return f"/api/zones/{zone.zone_id}/cover-art?v={version}"
+
+
 def _zone_summary(zone):
     speakers = []
     try:
@@ -163,6 +186,7 @@ def _zone_summary(zone):
         player, player_error = zone_manager.get_player_status(zone.zone_id)
 
     policy = zone_manager.get_tts_policy(zone.zone_id)[0] or {}
+    now_playing = zone.now_playing()
     return {
         "zone_id": zone.zone_id,
         "zone_name": zone.display_name,
@@ -173,7 +197,11 @@ def _zone_summary(zone):
         "default_lionos_room": bool(zone.config.get("default_lionos_room", False)),
         "interface": zone.interface,
         "auto_start": bool(zone.config.get("auto_start", False)),
+        "restart_on_failure": bool(zone.config.get("restart_on_failure", False)),
         "latency_offset": zone.config.get("latency_offset"),
+        "static_ip": zone.config.get("static_ip"),
+        "static_gateway": zone.config.get("static_gateway"),
+        "shairport_args": zone.config.get("shairport_args", []),
         "shairport_ip": zone.shairport_ip,
         "shairport_port": zone.shairport_port,
         "owntone_ip": zone.owntone_ip,
@@ -181,6 +209,11 @@ def _zone_summary(zone):
         "allocated_subdevice": zone.allocated_subdevice,
         "volume": volume,
         "volume_error": volume_error,
+        "muted": zone.muted,
+        "now_playing": now_playing,
+        "cover_art_url": _cover_art_url(zone, now_playing),
+        "speaker_groups": zone.config.get("speaker_groups", []),
+        "password_speakers": sorted(zone.config.get("speaker_passwords") or {}),
         "player": player or {},
         "player_error": player_error,
         "speakers": speakers,
@@ -287,9 +320,22 @@ def static_files(path):
 def system_status():
     return jsonify(zone_manager.get_system_status())
 
+@app.route("/api/system/checks")
+def system_checks():
+    return jsonify(run_checks(zone_manager))
+
 @app.route("/api/system/interfaces")
 def system_interfaces():
-    return jsonify({"interfaces": zone_manager.get_network_interfaces()})
+    include_virtual = request.args.get("all", "").lower() in {"1", "true", "yes"}
+    interfaces = zone_manager.get_network_interfaces(include_virtual=include_virtual)
+    return jsonify({
+        "interfaces": interfaces,
+        "details": zone_manager.get_network_interface_details(interfaces),
+    })
+
+@app.route("/metrics")
+def prometheus_metrics():
+    return Response(render_metrics(zone_manager), mimetype=METRICS_CONTENT_TYPE)
 
 @app.route("/api/settings", methods=["GET"])
 def get_settings():
@@ -301,6 +347,28 @@ def update_settings():
     updates = {}
     if "default_interface" in data:
         updates["default_interface"] = str(data.get("default_interface") or "").strip()
+    if "start_stagger_seconds" in data:
+        try:
+            stagger = float(data.get("start_stagger_seconds"))
+        except (TypeError, ValueError):
+            return jsonify({"error": "start_stagger_seconds must be a number"}), 400
+        if not 0 <= stagger <= MAX_START_STAGGER_SECONDS:
+            return jsonify({
+                "error": f"start_stagger_seconds should be between 0 and {MAX_START_STAGGER_SECONDS:g}"
+            }), 400
+        updates["start_stagger_seconds"] = stagger
+    if "interface_allowlist" in data:
+        allowlist = data.get("interface_allowlist") or []
+        if isinstance(allowlist, str):
+            allowlist = allowlist.split(",")
+        if not isinstance(allowlist, list):
+            return jsonify({"error": "interface_allowlist must be a list of interface names"}), 400
+        updates["interface_allowlist"] = sorted({str(name).strip() for name in allowlist if str(name).strip()})
+    if "speaker_groups" in data:
+        try:
+            updates["speaker_groups"] = normalize_speaker_groups(data.get("speaker_groups"))
+        except SpeakerGroupError as e:
+            return jsonify({"error": str(e)}), 400
     if updates:
         config_store.update_settings(updates)
     return jsonify({"settings": _public_settings()})
@@ -339,14 +407,27 @@ def create_zone():
                 "error": f"latency_offset should be between -{MAX_SHAIRPORT_LATENCY_OFFSET} and +{MAX_SHAIRPORT_LATENCY_OFFSET} seconds"
             }), 400
 
-    zone = zone_manager.create_zone(
-        name=name,
-        interface=interface,
-        auto_start=data.get("auto_start", False),
-        latency_offset=latency_offset,
-    )
+    try:
+        zone = zone_manager.create_zone(
+            name=name,
+            interface=interface,
+            auto_start=data.get("auto_start", False),
+            latency_offset=latency_offset,
+        )
+    except InvalidZoneNameError as e:
+        return jsonify({"error": str(e)}), 400
+    except DuplicateZoneNameError as e:
+        return jsonify({"error": str(e)}), 409
     return jsonify(zone.to_dict()), 201
 
+@app.route("/api/zones/order", methods=["PUT"])
+def reorder_zones():
+    data = request.get_json() or {}
+    order, error = zone_manager.reorder_zones(data.get("zone_ids"))
+    if error:
+        return jsonify({"error": error}), 400
+    return jsonify({"zone_ids": order})
+
 @app.route("/api/zones/<zone_id>")
 def get_zone(zone_id):
     zone = zone_manager.get_zone(zone_id)
@@ -358,7 +439,14 @@ def get_zone(zone_id):
 def update_zone(zone_id):
     data = request.get_json() or {}
     # Allow updating running zones - they will be restarted automatically
-    zone, restarted = zone_manager.update_zone_config(zone_id, data, restart_if_running=True)
+    if "name" in data and not str(data.get("name") or "").strip():
+        return jsonify({"error": "Zone name is required"}), 400
+    try:
+        zone, restarted = zone_manager.update_zone_config(zone_id, data, restart_if_running=True)
+    except DuplicateZoneNameError as e:
+        return jsonify({"error": str(e)}), 409
+    except (InvalidZoneNameError, StaticAddressError, SpeakerGroupError, ShairportArgsError) as e:
+        return jsonify({"error": str(e)}), 400
     if not zone:
         return jsonify({"error": "Zone not found"}), 400
     result = zone.to_dict()
@@ -367,10 +455,14 @@ def update_zone(zone_id):
 
 @app.route("/api/zones/<zone_id>", methods=["DELETE"])
 def delete_zone(zone_id):
-    if zone_manager.delete_zone(zone_id):
+    force = request.args.get("force", "").lower() in {"1", "true", "yes"}
+    deleted, error = zone_manager.delete_zone(zone_id, force=force)
+    if deleted:
         stop_log_watch(zone_id)
         return jsonify({"ok": True})
-    return jsonify({"error": "Zone not found"}), 404
+    if error == "Zone not found":
+        return jsonify({"error": error}), 404
+    return jsonify({"error": error}), 409
 
 # ---------------------------------------------------------------------------
 # Zone lifecycle API
@@ -383,6 +475,27 @@ def start_zone(zone_id):
         return jsonify({"ok": True})
     return jsonify({"error": "Cannot start zone (not found or already running)"}), 400
 
+@app.route("/api/zones/start-all", methods=["POST"])
+def start_all_zones():
+    data = request.get_json(silent=True) or {}
+    report = zone_manager.start_all(
+        zone_ids=data.get("zone_ids"),
+        stagger=data.get("stagger_seconds"),
+    )
+    for zone_id in report["queued"]:
+        start_log_watch(zone_id)
+    return jsonify(report)
+
+@app.route("/api/zones/stop-all", methods=["POST"])
+def stop_all_zones():
+    data = request.get_json(silent=True) or {}
+    # Teardown can take ~30s; progress arrives as zone_status events and the
+    # final report as stop_all_finished.
+    report = zone_manager.stop_all(zone_ids=data.get("zone_ids"), wait=False)
+    for zone_id in report["stopping"]:
+        stop_log_watch(zone_id)
+    return jsonify(report), 202
+
 @app.route("/api/zones/<zone_id>/stop", methods=["POST"])
 def stop_zone(zone_id):
     if zone_manager.stop_zone(zone_id):
@@ -555,6 +668,22 @@ def toggle_speaker(zone_id, speaker_id):
         return jsonify({"error": error}), 400
     return jsonify({"ok": True})
 
+@app.route("/api/zones/<zone_id>/speakers/<speaker_id>/reconnect", methods=["POST"])
+def reconnect_speaker(zone_id, speaker_id):
+    ok, error = zone_manager.reconnect_speaker(zone_id, speaker_id)
+    if error:
+        return jsonify({"error": error}), 400
+    # The speaker is re-enabled shortly after; the result arrives as speaker_reconnected.
+    return jsonify({"ok": True}), 202
+
+@app.route("/api/zones/<zone_id>/speakers/<speaker_id>/password", methods=["PUT"])
+def set_speaker_password(zone_id, speaker_id):
+    data = request.get_json() or {}
+    result, error = zone_manager.set_speaker_password(zone_id, speaker_id, data.get("password", ""))
+    if error:
+        return jsonify({"error": error}), 404 if error.endswith("not found") else 400
+    return jsonify(result)
+
 # ---------------------------------------------------------------------------
 # Volume API
 # ---------------------------------------------------------------------------
@@ -575,6 +704,14 @@ def set_volume(zone_id):
         return jsonify({"error": error}), 400
     return jsonify({"ok": True, "volume": volume})
 
+@app.route("/api/zones/<zone_id>/mute", methods=["PUT"])
+def set_mute(zone_id):
+    data = request.get_json() or {}
+    result, error = zone_manager.set_muted(zone_id, bool(data.get("muted", True)))
+    if error:
+        return jsonify({"error": error}), 400
+    return jsonify(result)
+
 @app.route("/api/zones/<zone_id>/speakers/<speaker_id>/volume", methods=["PUT"])
 def set_speaker_volume(zone_id, speaker_id):
     data = request.get_json() or {}
@@ -634,6 +771,30 @@ def get_player(zone_id):
         return jsonify({"error": error}), 400
     return jsonify(status or {})
 
+@app.route("/api/zones/<zone_id>/now-playing")
+def get_now_playing(zone_id):
+    zone = zone_manager.get_zone(zone_id)
+    if not zone:
+        return jsonify({"error": "Zone not found"}), 404
+    now_playing = zone.now_playing()
+    return jsonify({
+        "zone_id": zone.zone_id,
+        "zone_name": zone.display_name,
+        "status": zone.status,
+        "now_playing": now_playing,
+        "cover_art_url": _cover_art_url(zone, now_playing),
+    })
+
+@app.route("/api/zones/<zone_id>/cover-art")
+def get_cover_art(zone_id):
+    zone = zone_manager.get_zone(zone_id)
+    if not zone:
+        return jsonify({"error": "Zone not found"}), 404
+    data, mime = zone.cover_art()
+    if not data:
+        return jsonify({"error": "No cover art"}), 404
+    return Response(data, mimetype=mime, headers={"Cache-Control": "no-cache"})
+
 # ---------------------------------------------------------------------------
 # Logs API
 # ---------------------------------------------------------------------------
@@ -719,11 +880,15 @@ def startup():
     zone_manager.load_saved_zones()
     zone_manager.cleanup_orphaned_group_dirs()
 
-    # Auto-start zones that have auto_start=True
+    # Auto-start zones that have auto_start=True, staggered so their macvlan
+    # and DHCP setup on the shared NIC do not race.
+    auto_start_ids = []
     for zone in zone_manager.list_zones():
         if zone.config.get("auto_start", False):
             log.info("Auto-starting zone: %s", zone.display_name)
-            zone_manager.start_zone(zone.zone_id)
+            auto_start_ids.append(zone.zone_id)
+    if auto_start_ids:
+        zone_manager.start_all(auto_start_ids)
 
     # Start diagnostic monitor for AirPlay disconnect debugging
     zone_manager.start_diagnostic_monitor()
@@ -144,6 +144,8 @@ class GstZoneMixer:
         self._last_duck_update = time.monotonic()
         self._last_pipe_wait_log = 0.0
 
+        self._music_muted = False
+
         self._tts_duck_gain = clamp_float(tts_duck_gain, 0.0, 1.0, DEFAULT_DUCK_GAIN)
         self._tts_active = False
         self._tts_last_activity_at = 0.0
@@ -517,6 +519,8 @@ class GstZoneMixer:
         action = str(payload.get("action") or "offer").lower()
         if action == "control":
             return self._handle_tts_webrtc_control(payload)
+        if action == "mute":
+            return self._handle_mute(payload)
         if action != "offer":
             raise ValueError(f"Unsupported TTS WebRTC mixer action: {action}")
         if self.pipeline is None or self.mixer is None or self.tts_appsrc is None:
@@ -690,6 +694,13 @@ class GstZoneMixer:
             "persistent": True,
         }
 
+    def _handle_mute(self, payload: dict[str, Any]) -> dict[str, Any]:
+        # Muting only silences the AirPlay music branch. The pipeline, the
+        # OwnTone FIFO, and the speaker sessions stay up, and TTS still plays.
+        self._music_muted = bool(payload.get("muted"))
+        log.info("Music %s", "muted" if self._music_muted else "unmuted")
+        return {"ok": True, "muted": self._music_muted}
+
     def _add_and_link(self, elements: list[object]) -> None:
         for element in elements:
             self.pipeline.add(element)
@@ -834,7 +845,8 @@ class GstZoneMixer:
             self._duck_level = max(target, self._duck_level - step)
         else:
             self._duck_level = min(target, self._duck_level + step)
-        set_property_if_present(self.music_mixer_pad, "volume", self._duck_level)
+        volume = 0.0 if self._music_muted else self._duck_level
+        set_property_if_present(self.music_mixer_pad, "volume", volume)
 
     def _stop_pipeline(self) -> None:
         for session_id in list(self._sessions):
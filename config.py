@@ -13,12 +13,20 @@ import logging
 import os
 import shutil
 import threading
+import time
 
 log = logging.getLogger("shiri.config")
 
 BASE_DIR = "/var/lib/shiri"
 LOOPBACK_LOCK_DIR = os.path.join(BASE_DIR, "loopback")
-CONFIG_PATH = os.path.join(BASE_DIR, "config.json")
+# snd-aloop is loaded with pcm_substreams=16.
+LOOPBACK_SUBDEVICE_COUNT = 16
+# config.json and owntone.conf may hold speaker passwords.
+PRIVATE_FILE_MODE = 0o600
+DEFAULT_CONFIG_PATH = os.path.join(BASE_DIR, "config.json")
+# OwnTone's output type for AirPlay speakers, the only ones airplay config
+# blocks apply to.
+AIRPLAY_OUTPUT_TYPE = "AirPlay 2"
 _LOOPBACK_ALLOC_LOCK = threading.Lock()
 OWNTONE_PORT_BASE = 3869
 OWNTONE_WEBSOCKET_PORT_BASE = 3868
@@ -47,6 +55,16 @@ MIXER_SCRIPT = os.path.join(_THIS_DIR, "audio_mixer.py")
 DEFAULT_LATENCY_OFFSET = 0.0
 MAX_SHAIRPORT_LATENCY_OFFSET = 0.25
 
+# Bump CONFIG_VERSION and add a step to _MIGRATIONS whenever the on-disk shape
+# of config.json changes. Version 0 is every document written before the
+# version field existed.
+CONFIG_VERSION = 1
+LEGACY_ZONE_CONFIG_KEYS = {"network_mode", "netns_name", "macvlan_if"}
+
+
+class ConfigVersionError(RuntimeError):
+    """config.json was written by a newer Shiri than this one."""
+
 
 def normalize_latency_offset(value, default=DEFAULT_LATENCY_OFFSET):
     try:
@@ -70,31 +88,129 @@ def sanitize_audio_settings(raw):
     return config
 
 
+def zone_name_key(name):
+    """Normalize a zone name for uniqueness checks (trimmed, case-insensitive)."""
+    return " ".join(str(name or "").split()).casefold()
+
+
+def _migrate_zone_v0_to_v1(zone_config):
+    """Drop pre-namespace keys and rename room_* binding keys to lionos_*."""
+    config = dict(zone_config or {})
+    for key in LEGACY_ZONE_CONFIG_KEYS:
+        config.pop(key, None)
+    legacy_room_id = config.pop("room_id", None)
+    legacy_room_name = config.pop("room_name", None)
+    legacy_default_room = config.pop("default_room", None)
+    if legacy_room_id and "lionos_room_id" not in config:
+        config["lionos_room_id"] = legacy_room_id
+    if legacy_room_name and "lionos_room_name" not in config:
+        config["lionos_room_name"] = legacy_room_name
+    if legacy_default_room is not None and "default_lionos_room" not in config:
+        config["default_lionos_room"] = bool(legacy_default_room)
+    return config
+
+
+def _migrate_v0_to_v1(data):
+    data["zones"] = {
+        zone_id: _migrate_zone_v0_to_v1(zone_config)
+        for zone_id, zone_config in data.get("zones", {}).items()
+    }
+    return data
+
+
+_MIGRATIONS = {
+    0: _migrate_v0_to_v1,
+}
+
+
+def migrate_config(data):
+    """Upgrade a loaded config document to CONFIG_VERSION in place.
+
+    Returns True when the document changed and should be rewritten.
+    Raises ConfigVersionError for documents from a newer release, so their
+    unknown fields are not silently dropped by the next save.
+    """
+    try:
+        version = int(data.get("version", 0))
+    except (TypeError, ValueError):
+        version = 0
+    if version > CONFIG_VERSION:
+        raise ConfigVersionError(
+            f"config.json is version {version}, but this Shiri only supports up to "
+            f"version {CONFIG_VERSION}. Upgrade Shiri or restore an older config."
+        )
+    start = version
+    while version < CONFIG_VERSION:
+        data = _MIGRATIONS[version](data)
+        version += 1
+    data["version"] = version
+    if version != start:
+        log.info("Migrated config from version %d to %d", start, version)
+        return True
+    return False
+
+
 # ===========================================================================
 # ConfigStore — persistent zone settings (JSON)
 # ===========================================================================
 
+def default_config_path():
+    """Return the config file path, honoring SHIRI_CONFIG.
+
+    SHIRI_CONFIG pins the config file elsewhere (backups, version control,
+    read-only /var); runtime state stays under BASE_DIR either way.
+    """
+    return os.path.abspath(os.environ.get("SHIRI_CONFIG") or DEFAULT_CONFIG_PATH)
+
+
 class ConfigStore:
     """Thread-safe JSON config store for zone definitions."""
 
-    def __init__(self, path=CONFIG_PATH):
-        self.path = path
+    def __init__(self, path=None):
+        self.path = path or default_config_path()
         self._lock = threading.Lock()
-        self._data = {"zones": {}, "settings": {"default_interface": ""}}
+        self._data = {"version": CONFIG_VERSION, "zones": {}, "settings": {"default_interface": ""}}
         self._load()
 
+    @property
+    def _tmp_path(self):
+        return f"{self.path}.tmp"
+
     def _load(self):
         """Load config from disk."""
+        if not os.path.exists(self.path) and os.path.exists(self._tmp_path):
+            # A crash between writing the temp file and renaming it leaves the
+            # complete new config behind; promote it instead of starting empty.
+            try:
+                with open(self._tmp_path, "r") as f:
+                    json.load(f)
+                os.replace(self._tmp_path, self.path)
+                log.warning("Recovered config from %s", self._tmp_path)
+            except (json.JSONDecodeError, IOError) as e:
+                log.error("Ignoring unreadable temp config %s: %s", self._tmp_path, e)
         if os.path.exists(self.path):
+            # Configs written before saves were owner-only may still be
+            # world-readable; they hold speaker passwords.
+            try:
+                os.chmod(self.path, PRIVATE_FILE_MODE)
+            except OSError as e:
+                log.warning("Could not restrict permissions on %s: %s", self.path, e)
             try:
                 with open(self.path, "r") as f:
                     self._data = json.load(f)
-            except (json.JSONDecodeError, IOError):
-                pass
+            except (json.JSONDecodeError, IOError) as e:
+                # Keep the unreadable file for inspection; the next save would
+                # otherwise overwrite it with an empty zone list.
+                backup = f"{self.path}.corrupt-{int(time.time())}"
+                log.error("Could not read config %s (%s); moved it to %s", self.path, e, backup)
+                try:
+                    os.replace(self.path, backup)
+                except OSError:
+                    pass
         # Ensure structure
         self._data.setdefault("zones", {})
         self._data.setdefault("settings", {"default_interface": ""})
-        changed = False
+        changed = migrate_config(self._data)
         for zone_id, zone_config in list(self._data["zones"].items()):
             sanitized = sanitize_audio_settings(zone_config)
             if sanitized != zone_config:
@@ -102,12 +218,33 @@ class ConfigStore:
                 changed = True
         if changed:
             self._save()
+        for name, zone_ids in self.duplicate_zone_names().items():
+            log.warning("Zones %s share the name %r; rename them so their AirPlay receivers do not collide",
+                        ", ".join(zone_ids), name)
+
+    def duplicate_zone_names(self):
+        """Return {name: [zone_id, ...]} for names used by more than one zone."""
+        seen = {}
+        for zone_id, zone_config in self._data.get("zones", {}).items():
+            key = zone_name_key(zone_config.get("name"))
+            if key:
+                seen.setdefault(key, []).append(zone_id)
+        return {name: ids for name, ids in seen.items() if len(ids) > 1}
 
     def _save(self):
-        """Write config to disk."""
-        os.makedirs(os.path.dirname(self.path), exist_ok=True)
-        with open(self.path, "w") as f:
+        """Write config to disk atomically (temp file, fsync, rename)."""
+        directory = os.path.dirname(self.path)
+        os.makedirs(directory, exist_ok=True)
+        with _open_private(self._tmp_path) as f:
             json.dump(self._data, f, indent=2)
+            f.flush()
+            os.fsync(f.fileno())
+        os.replace(self._tmp_path, self.path)
+        dir_fd = os.open(directory, os.O_RDONLY)
+        try:
+            os.fsync(dir_fd)
+        finally:
+            os.close(dir_fd)
 
     # -- Zone CRUD --
 
@@ -127,6 +264,15 @@ class ConfigStore:
             self._data["zones"][zone_id] = sanitize_audio_settings(config)
             self._save()
 
+    def reorder_zones(self, zone_ids):
+        """Persist zones in the given display order; zones not listed keep their order at the end."""
+        with self._lock:
+            zones = self._data["zones"]
+            ordered = {zone_id: zones[zone_id] for zone_id in zone_ids if zone_id in zones}
+            ordered.update((zone_id, config) for zone_id, config in zones.items() if zone_id not in ordered)
+            self._data["zones"] = ordered
+            self._save()
+
     def delete_zone(self, zone_id):
         """Remove a zone config."""
         with self._lock:
@@ -156,9 +302,17 @@ def _read_template(name):
         return f.read()
 
 
-def _write_file(path, content, executable=False):
-    """Write content to a file, optionally making it executable."""
-    with open(path, "w") as f:
+def _open_private(path):
+    """Open path for writing, readable by its owner only."""
+    fd = os.open(path, os.O_WRONLY | os.O_CREAT | os.O_TRUNC, PRIVATE_FILE_MODE)
+    # The mode only applies on create; fix a file left over with another mode.
+    os.fchmod(fd, PRIVATE_FILE_MODE)
+    return os.fdopen(fd, "w")
+
+
+def _write_file(path, content, executable=False, private=False):
+    """Write content to a file, optionally making it executable or owner-only."""
+    with (_open_private(path) if private else open(path, "w")) as f:
         f.write(content)
     if executable:
         os.chmod(path, 0o755)
@@ -225,37 +379,51 @@ def setup_directories(zone):
 # Loopback subdevice allocation
 # ===========================================================================
 
-def allocate_loopback_subdevice():
+def _claim_loopback_subdevice(i, my_pid):
+    lock_file = os.path.join(LOOPBACK_LOCK_DIR, f"subdev_{i}.lock")
+    try:
+        fd = os.open(lock_file, os.O_CREAT | os.O_EXCL | os.O_WRONLY)
+        os.write(fd, my_pid.encode())
+        os.close(fd)
+        log.info("Allocated loopback subdevice %d", i)
+        return True
+    except FileExistsError:
+        try:
+            with open(lock_file, "r") as f:
+                pid = f.read().strip()
+            if not _lock_owner_is_live_shiri(pid):
+                os.remove(lock_file)
+                fd = os.open(lock_file, os.O_CREAT | os.O_EXCL | os.O_WRONLY)
+                os.write(fd, my_pid.encode())
+                os.close(fd)
+                log.info("Allocated loopback subdevice %d (reclaimed stale)", i)
+                return True
+        except (IOError, FileExistsError):
+            pass
+    return False
+
+
+def allocate_loopback_subdevice(preferred=None):
     """
     File-based locking in /var/lib/shiri/loopback/.
+
+    The zone's preferred subdevice is tried first so its ports stay the same
+    across restarts; any free one is used if it is taken.
     """
     with _LOOPBACK_ALLOC_LOCK:
         os.makedirs(LOOPBACK_LOCK_DIR, exist_ok=True)
         my_pid = str(os.getpid())
 
-        for i in range(16):
-            lock_file = os.path.join(LOOPBACK_LOCK_DIR, f"subdev_{i}.lock")
-            try:
-                fd = os.open(lock_file, os.O_CREAT | os.O_EXCL | os.O_WRONLY)
-                os.write(fd, my_pid.encode())
-                os.close(fd)
-                log.info("Allocated loopback subdevice %d", i)
+        if preferred is not None and 0 <= preferred < LOOPBACK_SUBDEVICE_COUNT:
+            if _claim_loopback_subdevice(preferred, my_pid):
+                return preferred
+            log.warning("Preferred loopback subdevice %d is in use; its zone gets different ports this run",
+                        preferred)
+        for i in range(LOOPBACK_SUBDEVICE_COUNT):
+            if i != preferred and _claim_loopback_subdevice(i, my_pid):
                 return i
-            except FileExistsError:
-                try:
-                    with open(lock_file, "r") as f:
-                        pid = f.read().strip()
-                    if not _lock_owner_is_live_shiri(pid):
-                        os.remove(lock_file)
-                        fd = os.open(lock_file, os.O_CREAT | os.O_EXCL | os.O_WRONLY)
-                        os.write(fd, my_pid.encode())
-                        os.close(fd)
-                        log.info("Allocated loopback subdevice %d (reclaimed stale)", i)
-                        return i
-                except (IOError, FileExistsError):
-                    continue
-
-    log.error("No free loopback subdevices available (all 16 in use)")
+
+    log.error("No free loopback subdevices available (all %d in use)", LOOPBACK_SUBDEVICE_COUNT)
     return None
 
 
@@ -364,9 +532,48 @@ def generate_owntone_config(zone):
         for name in getattr(zone, "excluded_airplay_names", [])
         if str(name).strip()
     })
+    # Saved speakers, including those from the zone's speaker groups, stay
+    # selected through power cycles and network blips: permanent keeps
+    # OwnTone retrying after a failure, reconnect covers devices that drop
+    # the session mid-stream.
+    saved_names = {
+        str(speaker.get("name"))
+        for speaker in zone.config.get("speaker_names", [])
+        if isinstance(speaker, dict) and str(speaker.get("name") or "").strip()
+    }
+    saved_names.update(
+        str(name) for name in getattr(zone, "group_speaker_names", []) if str(name).strip()
+    )
+    passwords = {
+        str(name): str(password)
+        for name, password in (zone.config.get("speaker_passwords") or {}).items()
+        if str(name).strip() and password
+    }
+    # airplay blocks only apply to AirPlay outputs. Names saved before types
+    # were recorded (and group speakers no zone has selected yet) are assumed
+    # to be AirPlay, the common case.
+    speaker_types = getattr(zone, "speaker_types", {})
+    saved_names = {
+        name for name in saved_names
+        if speaker_types.get(name, AIRPLAY_OUTPUT_TYPE) == AIRPLAY_OUTPUT_TYPE
+    }
+    passwords = {
+        name: password for name, password in passwords.items()
+        if speaker_types.get(name, AIRPLAY_OUTPUT_TYPE) == AIRPLAY_OUTPUT_TYPE
+    }
+    device_settings = {}
+    for name in excluded_names:
+        device_settings[name] = ["exclude = true"]
+    for name in sorted((saved_names | set(passwords)) - set(excluded_names)):
+        lines = []
+        if name in saved_names:
+            lines += ["permanent = true", "reconnect = true"]
+        if name in passwords:
+            lines.append(f'password = "{_owntone_quoted(passwords[name])}"')
+        device_settings[name] = lines
     airplay_blocks = "\n".join(
-        f'airplay "{_owntone_quoted(name)}" {{\n\texclude = true\n}}\n'
-        for name in excluded_names
+        f'airplay "{_owntone_quoted(name)}" {{\n' + "".join(f"\t{line}\n" for line in lines) + "}\n"
+        for name, lines in device_settings.items()
     )
     content = (template
                .replace("%%ZONE_ID%%", zone.zone_id)
@@ -376,7 +583,8 @@ def generate_owntone_config(zone):
                .replace("%%OWNTONE_WEBSOCKET_PORT%%", str(websocket_port))
                .replace("%%OWNTONE_MPD_PORT%%", str(mpd_port))
                .replace("%%AIRPLAY_DEVICE_BLOCKS%%", airplay_blocks))
-    _write_file(conf_path, content)
+    # May contain speaker passwords.
+    _write_file(conf_path, content, private=True)
 
     log.info("Generated OwnTone config for %s", zone.zone_id)
 
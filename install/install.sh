@@ -27,6 +27,7 @@ apt install -y \
   avahi-daemon \
   jq \
   curl \
+  iputils-ping \
   coreutils \
   build-essential \
   git \
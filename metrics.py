@@ -0,0 +1,112 @@
+"""
+Prometheus text-format metrics for Shiri.
+
+Served at GET /metrics. Everything is read from ZoneManager state at scrape
+time; the only per-scrape I/O is one OwnTone outputs request per running zone.
+"""
+
+import logging
+
+from zone import Zone
+
+log = logging.getLogger("shiri.metrics")
+
+METRICS_CONTENT_TYPE = "text/plain; version=0.0.4; charset=utf-8"
+
+ZONE_STATES = (
+    Zone.STATUS_STOPPED,
+    Zone.STATUS_STARTING,
+    Zone.STATUS_RUNNING,
+    Zone.STATUS_STOPPING,
+    Zone.STATUS_ERROR,
+)
+
+
+def _escape(value):
+    return str(value).replace("\\", "\\\\").replace("\n", "\\n").replace('"', '\\"')
+
+
+def _labels(**labels):
+    return "{" + ",".join(f'{key}="{_escape(value)}"' for key, value in labels.items()) + "}"
+
+
+class _Family:
+    def __init__(self, name, metric_type, help_text):
+        self.name = name
+        self.metric_type = metric_type
+        self.help_text = help_text
+        self.samples = []
+
+    def add(self, value, **labels):
+        self.samples.append((labels, value))
+
+    def render(self):
+        lines = [
+            f"# HELP {self.name} {self.help_text}",
+            f"# TYPE {self.name} {self.metric_type}",
+        ]
+        for labels, value in self.samples:
+            lines.append(f"{self.name}{_labels(**labels) if labels else ''} {value}")
+        return lines
+
+
+def _zone_outputs(zone_manager, zone):
+    if zone.status != Zone.STATUS_RUNNING or not zone.owntone_api:
+        return None
+    try:
+        outputs, error = zone_manager.get_speakers(zone.zone_id)
+    except Exception as e:
+        log.debug("Could not read outputs for %s metrics: %s", zone.zone_id, e)
+        return None
+    if error:
+        log.debug("Could not read outputs for %s metrics: %s", zone.zone_id, error)
+    return outputs
+
+
+def render_metrics(zone_manager):
+    """Return the current metrics as Prometheus exposition text."""
+    zones = zone_manager.list_zones()
+    zone_count = _Family("shiri_zones", "gauge", "Configured zones.")
+    zone_count.add(len(zones))
+    state = _Family("shiri_zone_state", "gauge", "Zone lifecycle state; 1 for the current state.")
+    up = _Family("shiri_zone_up", "gauge", "1 if the zone is running.")
+    playing = _Family("shiri_zone_playing", "gauge", "1 if an AirPlay sender is streaming to the zone.")
+    speakers_selected = _Family("shiri_zone_speakers_selected", "gauge",
+                                "Speakers OwnTone is currently sending the zone to.")
+    speakers_available = _Family("shiri_zone_speakers_available", "gauge",
+                                 "Speakers OwnTone can see from the zone.")
+    speaker_up = _Family("shiri_speaker_up", "gauge", "1 if the speaker is selected and OwnTone is sending to it.")
+    mixer_restarts = _Family("shiri_zone_mixer_restarts_total", "counter",
+                             "Mixer restarts after unexpected exits.")
+    process_failures = _Family("shiri_zone_process_failures_total", "counter",
+                               "Shairport or OwnTone exits detected while the zone was running.")
+
+    for zone in zones:
+        zone_labels = {"zone_id": zone.zone_id, "zone_name": zone.display_name}
+        for name in ZONE_STATES:
+            state.add(int(zone.status == name), state=name, **zone_labels)
+        up.add(int(zone.status == Zone.STATUS_RUNNING), **zone_labels)
+        now_playing = zone.now_playing() or {}
+        playing.add(int(now_playing.get("state") == "playing"), **zone_labels)
+        mixer_restarts.add(zone.mixer_restarts, **zone_labels)
+        process_failures.add(zone.process_failures, **zone_labels)
+
+        outputs = _zone_outputs(zone_manager, zone)
+        if outputs is None:
+            continue
+        selected = [output for output in outputs if output.get("selected")]
+        speakers_selected.add(len(selected), **zone_labels)
+        speakers_available.add(len(outputs), **zone_labels)
+        for output in outputs:
+            speaker_up.add(
+                int(bool(output.get("selected"))),
+                speaker_id=output.get("id"),
+                speaker_name=output.get("name", "Unknown"),
+                **zone_labels,
+            )
+
+    lines = []
+    for family in (zone_count, state, up, playing, speakers_selected, speakers_available,
+                   speaker_up, mixer_restarts, process_failures):
+        lines.extend(family.render())
+    return "\n".join(lines) + "\n"
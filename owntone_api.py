@@ -6,15 +6,37 @@ OwnTone HTTP API through the namespace veth address and unique per-zone port.
 """
 
 import json
+import os
 import subprocess
 import logging
 
 log = logging.getLogger("shiri.owntone")
 
 
-def _run_curl(url, method="GET", data=None, timeout=5):
+def _env_int(name, default, minimum):
+    try:
+        return max(minimum, int(os.environ.get(name, default)))
+    except ValueError:
+        log.warning("Ignoring invalid %s=%r", name, os.environ.get(name))
+        return default
+
+
+# Connect timeout per attempt, and how many times a PUT/POST is retried when
+# OwnTone is briefly busy (timeouts, refused connections, HTTP 408/429/5xx).
+DEFAULT_TIMEOUT = _env_int("SHIRI_OWNTONE_TIMEOUT", 5, 1)
+DEFAULT_RETRIES = _env_int("SHIRI_OWNTONE_RETRIES", 2, 0)
+RETRY_DELAY_SECONDS = 1
+
+
+def _run_curl(url, method="GET", data=None, timeout=DEFAULT_TIMEOUT, retries=0):
     """Run curl against a zone OwnTone API endpoint."""
     curl_cmd = ["curl", "-s", "--connect-timeout", str(timeout)]
+    if retries:
+        curl_cmd += [
+            "--retry", str(retries),
+            "--retry-delay", str(RETRY_DELAY_SECONDS),
+            "--retry-connrefused",
+        ]
 
     if method == "PUT":
         curl_cmd += ["-X", "PUT"]
@@ -28,7 +50,8 @@ def _run_curl(url, method="GET", data=None, timeout=5):
 
     try:
         result = subprocess.run(
-            curl_cmd, capture_output=True, text=True, timeout=timeout + 5
+            curl_cmd, capture_output=True, text=True,
+            timeout=(timeout + 5 + RETRY_DELAY_SECONDS) * (retries + 1),
         )
         if result.returncode != 0:
             log.warning("curl failed (rc=%d): %s", result.returncode, result.stderr)
@@ -50,15 +73,22 @@ def _run_curl(url, method="GET", data=None, timeout=5):
 class OwnToneAPI:
     """OwnTone REST API client for a single Shiri zone."""
 
-    def __init__(self, owntone_ip, port=3689):
+    def __init__(self, owntone_ip, port=3689, timeout=DEFAULT_TIMEOUT, retries=DEFAULT_RETRIES):
         self.owntone_ip = owntone_ip
         self.port = int(port)
         self.base_url = f"http://{owntone_ip}:{self.port}"
+        self.timeout = timeout
+        self.retries = retries
 
     def _api(self, path, method="GET", data=None):
-        """Call OwnTone API endpoint."""
+        """Call OwnTone API endpoint.
+
+        Only commands are retried; GETs are polled by callers that already
+        loop, and retrying them would stall the diagnostic monitor.
+        """
         url = f"{self.base_url}{path}"
-        return _run_curl(url, method=method, data=data)
+        retries = self.retries if method != "GET" else 0
+        return _run_curl(url, method=method, data=data, timeout=self.timeout, retries=retries)
 
     # -- Config / Health --
 
@@ -0,0 +1,213 @@
+"""
+Shairport Sync metadata pipe reader for Shiri.
+
+Shairport writes now-playing metadata for each zone into
+groups/<zone>/pipes/shairport.metadata as a stream of XML-ish items:
+
+    <item><type>636f7265</type><code>6d696e6d</code><length>9</length>
+    <data encoding="base64">
+    U2hhcm9uIFZhbg==</data></item>
+
+type and code are hex-encoded four character codes ("core"/"minm" is the
+track title); data is optional. The reader keeps the latest track, artist,
+album and cover art per zone and calls on_update when a metadata bundle or
+the play state changes.
+"""
+
+import base64
+import binascii
+import errno
+import logging
+import os
+import re
+import select
+import threading
+import time
+
+log = logging.getLogger("shiri.metadata")
+
+_ITEM_RE = re.compile(
+    rb"<item><type>([0-9a-fA-F]{8})</type><code>([0-9a-fA-F]{8})</code>"
+    rb"<length>(\d+)</length>\s*"
+    rb"(?:<data encoding=\"base64\">\s*([A-Za-z0-9+/=\s]*)</data>\s*)?</item>"
+)
+# Drop unparseable input rather than buffering it forever.
+MAX_BUFFER_BYTES = 8 * 1024 * 1024
+
+# DAAP/DMAP codes Shairport forwards from the sender ("core" type).
+_CORE_FIELDS = {
+    "minm": "title",
+    "asar": "artist",
+    "asal": "album",
+    "asgn": "genre",
+    "ascp": "composer",
+}
+# Shairport's own session events ("ssnc" type).
+_PLAY_STATES = {
+    "pbeg": "playing",
+    "prsm": "playing",
+    "pfls": "paused",
+    "pend": "stopped",
+}
+
+
+def _fourcc(hex_text):
+    try:
+        return binascii.unhexlify(hex_text).decode("ascii", errors="replace")
+    except (binascii.Error, ValueError):
+        return ""
+
+
+def _image_mime(data):
+    if data.startswith(b"\xff\xd8"):
+        return "image/jpeg"
+    if data.startswith(b"\x89PNG"):
+        return "image/png"
+    return "application/octet-stream"
+
+
+class MetadataReader:
+    """Follow one zone's Shairport metadata FIFO on a background thread."""
+
+    def __init__(self, zone_id, pipe_path, on_update=None):
+        self.zone_id = zone_id
+        self.pipe_path = pipe_path
+        self.on_update = on_update
+        self._lock = threading.Lock()
+        self._stop = threading.Event()
+        self._thread = None
+        self._now_playing = self._empty_state()
+        self._pending = {}
+        self._cover = None
+        self._cover_mime = None
+
+    @staticmethod
+    def _empty_state():
+        return {
+            "state": "stopped",
+            "title": None,
+            "artist": None,
+            "album": None,
+            "genre": None,
+            "composer": None,
+            "source": None,
+            "has_cover_art": False,
+            "updated_at": None,
+        }
+
+    def start(self):
+        self._thread = threading.Thread(
+            target=self._run, daemon=True, name=f"metadata-{self.zone_id}"
+        )
+        self._thread.start()
+
+    def stop(self, timeout=2):
+        self._stop.set()
+        if self._thread:
+            self._thread.join(timeout)
+            self._thread = None
+
+    def now_playing(self):
+        with self._lock:
+            return dict(self._now_playing)
+
+    def cover_art(self):
+        """Return (bytes, mime) for the current cover art, or (None, None)."""
+        with self._lock:
+            return self._cover, self._cover_mime
+
+    def _run(self):
+        while not self._stop.is_set():
+            try:
+                self._follow_pipe()
+            except OSError as e:
+                log.warning("Metadata pipe for %s failed: %s", self.zone_id, e)
+            # Pipe vanished (zone restarting) or failed; retry until stopped.
+            self._stop.wait(1)
+
+    def _follow_pipe(self):
+        # Opening read-only and non-blocking never waits for Shairport. A
+        # writer fd of our own keeps the FIFO from reporting EOF while
+        # Shairport is between sessions and lets its non-blocking open succeed.
+        read_fd = os.open(self.pipe_path, os.O_RDONLY | os.O_NONBLOCK)
+        try:
+            hold_fd = os.open(self.pipe_path, os.O_WRONLY | os.O_NONBLOCK)
+        except OSError:
+            os.close(read_fd)
+            raise
+        buffer = b""
+        try:
+            while not self._stop.is_set():
+                readable, _, _ = select.select([read_fd], [], [], 0.5)
+                if not readable:
+                    continue
+                try:
+                    chunk = os.read(read_fd, 65536)
+                except OSError as e:
+                    if e.errno == errno.EAGAIN:
+                        continue
+                    raise
+                if not chunk:
+                    continue
+                buffer = self._consume(buffer + chunk)
+                if len(buffer) > MAX_BUFFER_BYTES:
+                    log.warning("Discarding %d bytes of unparseable metadata for %s",
+                                len(buffer), self.zone_id)
+                    buffer = b""
+        finally:
+            os.close(hold_fd)
+            os.close(read_fd)
+
+    def _consume(self, buffer):
+        end = 0
+        for match in _ITEM_RE.finditer(buffer):
+            end = match.end()
+            item_type = _fourcc(match.group(1))
+            code = _fourcc(match.group(2))
+            data = b""
+            if match.group(4):
+                try:
+                    data = base64.b64decode(match.group(4), validate=False)
+                except (binascii.Error, ValueError):
+                    data = b""
+            self._handle_item(item_type, code, data)
+        return buffer[end:]
+
+    def _handle_item(self, item_type, code, data):
+        changed = False
+        with self._lock:
+            if item_type == "core" and code in _CORE_FIELDS:
+                self._pending[_CORE_FIELDS[code]] = data.decode("utf-8", errors="replace") or None
+            elif item_type == "ssnc" and code == "mdst":
+                self._pending = {}
+            elif item_type == "ssnc" and code == "mden":
+                for field in _CORE_FIELDS.values():
+                    self._now_playing[field] = self._pending.get(field)
+                self._pending = {}
+                changed = True
+            elif item_type == "ssnc" and code == "PICT":
+                # An empty PICT means the new track has no art.
+                self._cover = data or None
+                self._cover_mime = _image_mime(data) if data else None
+                self._now_playing["has_cover_art"] = bool(data)
+                changed = True
+            elif item_type == "ssnc" and code == "snam":
+                self._now_playing["source"] = data.decode("utf-8", errors="replace") or None
+                changed = True
+            elif item_type == "ssnc" and code in _PLAY_STATES:
+                state = _PLAY_STATES[code]
+                if state != self._now_playing["state"]:
+                    self._now_playing["state"] = state
+                    changed = True
+                if code == "pend":
+                    self._now_playing = self._empty_state()
+                    self._cover = None
+                    self._cover_mime = None
+                    changed = True
+            if changed:
+                self._now_playing["updated_at"] = time.time()
+        if changed and self.on_update:
+            try:
+                self.on_update()
+            except Exception as e:
+                log.debug("Metadata update callback for %s failed: %s", self.zone_id, e)
@@ -42,10 +42,17 @@ export const Api = {
     settings: () => api('/settings'),
     saveSettings: (body) => api('/settings', { method: 'PUT', body }),
     interfaces: () => api('/system/interfaces'),
+    systemChecks: () => api('/system/checks'),
     createZone: (body) => api('/zones', { method: 'POST', body }),
+    reorderZones: (zoneIds) => api('/zones/order', { method: 'PUT', body: { zone_ids: zoneIds } }),
     updateZone: (zoneId, body) => api(`/zones/${encodeURIComponent(zoneId)}`, { method: 'PUT', body }),
-    deleteZone: (zoneId) => api(`/zones/${encodeURIComponent(zoneId)}`, { method: 'DELETE' }),
+    deleteZone: (zoneId, { force = false } = {}) => api(
+        `/zones/${encodeURIComponent(zoneId)}${force ? '?force=1' : ''}`,
+        { method: 'DELETE' },
+    ),
     startZone: (zoneId) => api(`/zones/${encodeURIComponent(zoneId)}/start`, { method: 'POST' }),
+    startAllZones: (body = {}) => api('/zones/start-all', { method: 'POST', body }),
+    stopAllZones: (body = {}) => api('/zones/stop-all', { method: 'POST', body }),
     stopZone: (zoneId) => api(`/zones/${encodeURIComponent(zoneId)}/stop`, { method: 'POST' }),
     bindZone: (zoneId, body) => api(`/zones/${encodeURIComponent(zoneId)}/binding`, { method: 'PUT', body }),
     clearZoneBinding: (zoneId) => api(`/zones/${encodeURIComponent(zoneId)}/binding`, { method: 'DELETE' }),
@@ -53,6 +60,10 @@ export const Api = {
         method: 'PUT',
         body: { volume },
     }),
+    setZoneMuted: (zoneId, muted) => api(`/zones/${encodeURIComponent(zoneId)}/mute`, {
+        method: 'PUT',
+        body: { muted },
+    }),
     setZoneTtsPolicy: (zoneId, body) => api(`/zones/${encodeURIComponent(zoneId)}/tts-policy`, {
         method: 'PUT',
         body,
@@ -62,6 +73,14 @@ export const Api = {
         method: 'PUT',
         body: { speaker_ids: speakerIds },
     }),
+    reconnectSpeaker: (zoneId, speakerId) => api(
+        `/zones/${encodeURIComponent(zoneId)}/speakers/${encodeURIComponent(speakerId)}/reconnect`,
+        { method: 'POST' },
+    ),
+    setSpeakerPassword: (zoneId, speakerId, password) => api(
+        `/zones/${encodeURIComponent(zoneId)}/speakers/${encodeURIComponent(speakerId)}/password`,
+        { method: 'PUT', body: { password } },
+    ),
     setSpeakerVolume: (zoneId, speakerId, volume) => api(
         `/zones/${encodeURIComponent(zoneId)}/speakers/${encodeURIComponent(speakerId)}/volume`,
         { method: 'PUT', body: { volume } },
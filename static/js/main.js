@@ -4,15 +4,24 @@ import {
     clampNumber,
     debounce,
     escapeHtml,
+    formatShairportArgs,
+    formatSpeakerGroups,
+    interfaceLabel,
+    nowPlayingText,
+    parseSpeakerGroups,
+    readPref,
     selectedSpeakerText,
     statusClass,
+    writePref,
     zoneLabel,
+    zoneMatchesFilter,
 } from './utils.js';
 
 const state = {
     dashboard: null,
     activeZoneId: null,
     activeDrawerTab: 'setup',
+    zoneFilter: '',
     diagnosticsOpen: false,
     logsPaused: false,
     socket: null,
@@ -26,8 +35,10 @@ document.addEventListener('DOMContentLoaded', init);
 async function init() {
     bindElements();
     bindEvents();
+    applyTheme(readPref('theme', 'dark'));
     connectSocket();
     await loadDashboard();
+    restoreZoneDrawer();
     window.setInterval(() => loadDashboard({ quiet: true }), 8000);
 }
 
@@ -38,7 +49,11 @@ function bindElements() {
         'lionos-status',
         'default-room',
         'console-subtitle',
+        'zone-filter',
+        'start-all-zones',
+        'stop-all-zones',
         'refresh-dashboard',
+        'toggle-theme',
         'open-diagnostics',
         'open-settings',
         'global-error',
@@ -59,8 +74,12 @@ function bindElements() {
         'log-feed',
         'settings-panel',
         'settings-form',
+        'settings-interface-allowlist',
+        'settings-speaker-groups',
         'settings-zones',
         'refresh-settings',
+        'run-system-checks',
+        'system-checks',
         'create-zone-form',
         'new-zone-name',
         'new-zone-interface',
@@ -74,6 +93,13 @@ function bindElements() {
 
 function bindEvents() {
     els.refreshDashboard.addEventListener('click', () => loadDashboard());
+    els.zoneFilter.addEventListener('input', () => {
+        state.zoneFilter = els.zoneFilter.value;
+        renderDashboard();
+    });
+    els.startAllZones.addEventListener('click', startAllZones);
+    els.stopAllZones.addEventListener('click', stopAllZones);
+    els.toggleTheme.addEventListener('click', toggleTheme);
     els.openSettings.addEventListener('click', openSettings);
     els.closeSettings.addEventListener('click', closeSettings);
     els.openDiagnostics.addEventListener('click', openDiagnostics);
@@ -84,6 +110,7 @@ function bindEvents() {
     els.diagRoomFilter.addEventListener('change', loadLogs);
     els.diagTypeFilter.addEventListener('change', loadLogs);
     els.refreshSettings.addEventListener('click', renderSettings);
+    els.runSystemChecks.addEventListener('click', renderSystemChecks);
     els.settingsForm.addEventListener('submit', onSaveSettings);
     els.createZoneForm.addEventListener('submit', onCreateZone);
 
@@ -128,12 +155,16 @@ function renderDashboard() {
     renderStatusPill(els.shiriStatus, total ? `${running}/${total} zones running` : 'No zones', total ? (running ? 'good' : 'warn') : 'bad');
     renderStatusPill(els.lionosStatus, 'LionOS owns rooms', 'good');
     renderDefaultBinding();
-    els.globalError.hidden = true;
-    els.globalError.textContent = '';
-
-    els.roomList.innerHTML = zones.length
-        ? zones.map(renderZoneRow).join('')
-        : '<div class="empty-state">No zones found</div>';
+    const missing = dashboard.system?.missing_binaries || [];
+    els.globalError.hidden = !missing.length;
+    els.globalError.textContent = missing.length ? `Zones cannot start, not installed: ${missing.join(', ')}` : '';
+
+    const visible = zones.filter((zone) => zoneMatchesFilter(zone, state.zoneFilter));
+    if (visible.length) {
+        els.roomList.innerHTML = visible.map(renderZoneRow).join('');
+    } else {
+        els.roomList.innerHTML = `<div class="empty-state">${zones.length ? 'No zones match the filter' : 'No zones found'}</div>`;
+    }
 }
 
 function renderStatusPill(el, text, tone) {
@@ -176,6 +207,15 @@ function renderZoneRow(zone) {
                 <div class="speaker-summary" title="${escapeHtml(selectedSpeakerText(zone.speakers || []))}">
                     ${escapeHtml(selectedSpeakerText(zone.speakers || []))}
                 </div>
+                ${nowPlayingText(zone.now_playing) ? `
+                    <div class="now-playing" title="${escapeHtml(nowPlayingText(zone.now_playing))}">
+                        ${zone.cover_art_url ? `<img class="cover-thumb" src="${escapeHtml(zone.cover_art_url)}" alt="">` : ''}
+                        <span>${escapeHtml(nowPlayingText(zone.now_playing))}</span>
+                    </div>
+                ` : ''}
+                ${zone.status === 'error' && zone.error_message ? `
+                    <div class="zone-error" title="${escapeHtml(zone.error_message)}">${escapeHtml(zone.error_message)}</div>
+                ` : ''}
             </div>
             <div class="room-cell">
                 <div class="control-bank">
@@ -199,6 +239,7 @@ function renderZoneRow(zone) {
                 <div class="row-actions">
                     <button class="small-btn" data-action="zone-start" data-zone-id="${escapeHtml(zone.zone_id)}" ${zone.can_start ? '' : 'disabled'}>Start</button>
                     <button class="small-btn" data-action="zone-stop" data-zone-id="${escapeHtml(zone.zone_id)}" ${zone.can_stop ? '' : 'disabled'}>Stop</button>
+                    <button class="small-btn" data-action="zone-mute" data-zone-id="${escapeHtml(zone.zone_id)}" data-muted="${zone.muted ? 'true' : 'false'}" ${isRunning ? '' : 'disabled'}>${zone.muted ? 'Unmute' : 'Mute'}</button>
                     <button class="small-btn" data-action="zone-details" data-zone-id="${escapeHtml(zone.zone_id)}">Details</button>
                 </div>
             </div>
@@ -206,6 +247,54 @@ function renderZoneRow(zone) {
     `;
 }
 
+async function stopAllZones() {
+    if (!window.confirm('Stop every running zone?')) return;
+    els.stopAllZones.disabled = true;
+    try {
+        const report = await Api.stopAllZones();
+        const stopping = report.stopping?.length || 0;
+        showToast(stopping ? `Stopping ${stopping} zone${stopping === 1 ? '' : 's'}` : 'No running zones to stop');
+        refreshSoon();
+    } catch (error) {
+        showError(error);
+    } finally {
+        els.stopAllZones.disabled = false;
+    }
+}
+
+function onStopAllFinished({ stopped = [], failed = [] } = {}) {
+    if (failed.length) {
+        showError(new Error(`${stopped.length} stopped, ${failed.length} failed: ${failed.map((item) => `${item.name}: ${item.error}`).join('; ')}`));
+    } else if (stopped.length) {
+        showToast(`${stopped.length} zone${stopped.length === 1 ? '' : 's'} stopped`);
+    }
+    refreshSoon();
+}
+
+function onStartAllFinished({ results = [] } = {}) {
+    const failed = results.filter((item) => item.result !== 'ok');
+    if (failed.length) {
+        showError(new Error(`${results.length - failed.length} started, ${failed.length} failed: ${failed.map((item) => `${item.name}: ${item.result === 'timeout' ? 'timed out' : item.error}`).join('; ')}`));
+    } else if (results.length) {
+        showToast(`${results.length} zone${results.length === 1 ? '' : 's'} started`);
+    }
+    refreshSoon();
+}
+
+async function startAllZones() {
+    try {
+        const report = await Api.startAllZones();
+        const queued = report.queued?.length || 0;
+        const skipped = report.skipped?.length || 0;
+        showToast(queued
+            ? `Starting ${queued} zone${queued === 1 ? '' : 's'}${skipped ? `, ${skipped} skipped` : ''}`
+            : 'No stopped zones to start');
+        refreshSoon();
+    } catch (error) {
+        showError(error);
+    }
+}
+
 function onRangeInput(event) {
     if (event.target.type !== 'range') return;
     const output = (
@@ -228,6 +317,11 @@ async function onZoneListClick(event) {
             await Api.stopZone(button.dataset.zoneId);
             showToast('Zone stopping');
             refreshSoon();
+        } else if (action === 'zone-mute') {
+            const muted = button.dataset.muted !== 'true';
+            await Api.setZoneMuted(button.dataset.zoneId, muted);
+            showToast(muted ? 'Zone muted' : 'Zone unmuted');
+            refreshSoon();
         } else if (action === 'zone-details') {
             openZoneDrawer(button.dataset.zoneId);
         }
@@ -267,11 +361,24 @@ async function saveZonePolicyFromRow(zoneId) {
     showToast('Ducking saved');
 }
 
-function openZoneDrawer(zoneId) {
+function applyTheme(theme) {
+    const next = theme === 'light' ? 'light' : 'dark';
+    document.documentElement.dataset.theme = next;
+    if (els.toggleTheme) els.toggleTheme.textContent = next === 'light' ? 'Dark' : 'Light';
+}
+
+function toggleTheme() {
+    const next = document.documentElement.dataset.theme === 'light' ? 'dark' : 'light';
+    applyTheme(next);
+    writePref('theme', next);
+}
+
+function openZoneDrawer(zoneId, tab = 'setup') {
     state.activeZoneId = zoneId;
-    state.activeDrawerTab = 'setup';
+    state.activeDrawerTab = tab;
     els.roomDrawer.classList.add('open');
     els.roomDrawer.setAttribute('aria-hidden', 'false');
+    rememberZoneDrawer();
     renderZoneDrawer();
 }
 
@@ -279,6 +386,18 @@ function closeZoneDrawer() {
     state.activeZoneId = null;
     els.roomDrawer.classList.remove('open');
     els.roomDrawer.setAttribute('aria-hidden', 'true');
+    rememberZoneDrawer();
+}
+
+function rememberZoneDrawer() {
+    writePref('drawer', state.activeZoneId ? { zoneId: state.activeZoneId, tab: state.activeDrawerTab } : null);
+}
+
+function restoreZoneDrawer() {
+    const saved = readPref('drawer');
+    if (!saved || !findZone(saved.zoneId)) return;
+    const tab = ['setup', 'speakers', 'advanced'].includes(saved.tab) ? saved.tab : 'setup';
+    openZoneDrawer(saved.zoneId, tab);
 }
 
 function renderZoneDrawer() {
@@ -340,23 +459,44 @@ function renderDrawerSetup(zone) {
 
 function renderDrawerSpeakers(zone) {
     const speakers = zone.speakers || [];
-    if (!speakers.length) {
-        els.drawerSpeakers.innerHTML = '<div class="empty-state">No speakers discovered or saved</div>';
-        return;
-    }
     const enabledSpeakers = speakers.filter((speaker) => speaker.selected);
+    const routing = speakers.length ? `
+        <div class="drawer-block">
+            <div class="section-title">
+                <h3>Routing</h3>
+                <span class="mode-badge">${enabledSpeakers.length}/${speakers.length} enabled</span>
+            </div>
+            <div class="speaker-route-list">
+                ${speakers.map((speaker) => renderSpeakerRouteRow(zone, speaker)).join('')}
+            </div>
+            <button class="primary-btn" data-action="save-speakers" data-zone-id="${escapeHtml(zone.zone_id)}">Save Routing</button>
+        </div>
+    ` : '<div class="empty-state">No speakers discovered or saved</div>';
     els.drawerSpeakers.innerHTML = `
         <div class="drawer-stack">
-            <div class="drawer-block">
-                <div class="section-title">
-                    <h3>Routing</h3>
-                    <span class="mode-badge">${enabledSpeakers.length}/${speakers.length} enabled</span>
-                </div>
-                <div class="speaker-route-list">
-                    ${speakers.map((speaker) => renderSpeakerRouteRow(zone, speaker)).join('')}
-                </div>
-                <button class="primary-btn" data-action="save-speakers" data-zone-id="${escapeHtml(zone.zone_id)}">Save Routing</button>
+            ${routing}
+            ${renderSpeakerGroups(zone)}
+        </div>
+    `;
+}
+
+function renderSpeakerGroups(zone) {
+    const groups = state.dashboard?.settings?.speaker_groups || [];
+    if (!groups.length) return '';
+    const active = zone.speaker_groups || [];
+    return `
+        <div class="drawer-block">
+            <div class="section-title">
+                <h3>Speaker Groups</h3>
+                <span class="mode-badge">added at start</span>
             </div>
+            ${groups.map((group) => `
+                <label class="check-field" title="${escapeHtml(group.speakers.join(', '))}">
+                    <input type="checkbox" data-speaker-group="${escapeHtml(group.name)}" ${active.includes(group.name) ? 'checked' : ''}>
+                    <span>${escapeHtml(group.name)} (${escapeHtml(group.speakers.join(', '))})</span>
+                </label>
+            `).join('')}
+            <button class="primary-btn" data-action="save-speaker-groups" data-zone-id="${escapeHtml(zone.zone_id)}">Save Groups</button>
         </div>
     `;
 }
@@ -365,6 +505,7 @@ function renderSpeakerRouteRow(zone, speaker) {
     const speakerId = String(speaker.id ?? '');
     const volume = clampNumber(speaker.volume, 0, 100, 100);
     const selected = !!speaker.selected;
+    const hasPassword = (zone.password_speakers || []).includes(speaker.name);
     return `
         <div class="speaker-row speaker-route-row" data-speaker-id="${escapeHtml(speakerId)}" data-speaker-name="${escapeHtml(speaker.name || '')}">
             <div>
@@ -380,6 +521,13 @@ function renderSpeakerRouteRow(zone, speaker) {
                     <span>Volume</span>
                     <input type="range" min="0" max="100" value="${volume}" data-action="speaker-volume" data-zone-id="${escapeHtml(zone.zone_id)}" data-speaker-id="${escapeHtml(speakerId)}" ${zone.status === 'running' ? '' : 'disabled'}>
                     <output>${volume}%</output>
+                    <button class="small-btn" data-action="speaker-reconnect" data-zone-id="${escapeHtml(zone.zone_id)}" data-speaker-id="${escapeHtml(speakerId)}" ${zone.status === 'running' ? '' : 'disabled'}>Reconnect</button>
+                </div>
+            ` : ''}
+            ${speaker.requires_auth || hasPassword ? `
+                <div class="speaker-auth">
+                    <span>${hasPassword ? 'Password saved' : 'Password required'}</span>
+                    <button class="small-btn" data-action="speaker-password" data-zone-id="${escapeHtml(zone.zone_id)}" data-speaker-id="${escapeHtml(speakerId)}" data-speaker-name="${escapeHtml(speaker.name || speakerId)}">${hasPassword ? 'Change Password' : 'Set Password'}</button>
                 </div>
             ` : ''}
         </div>
@@ -388,28 +536,52 @@ function renderSpeakerRouteRow(zone, speaker) {
 
 function renderDrawerAdvanced(zone) {
     const interfaces = state.dashboard?.system?.interfaces || [];
+    const interfaceDetails = state.dashboard?.system?.interface_details || [];
     const ownTonePort = zone.owntone_port ?? 3689;
     els.drawerAdvanced.innerHTML = `
         <div class="drawer-stack">
             <label class="field">
                 <span>AirPlay name</span>
-                <input id="advanced-zone-name" type="text" value="${escapeHtml(zone.zone_name)}">
+                <input id="advanced-zone-name" type="text" maxlength="50" value="${escapeHtml(zone.zone_name)}">
             </label>
             <label class="field">
                 <span>Network interface</span>
                 <select id="advanced-zone-interface">
-                    ${interfaces.map((iface) => `<option value="${escapeHtml(iface)}" ${iface === zone.interface ? 'selected' : ''}>${escapeHtml(iface)}</option>`).join('')}
+                    ${interfaces.map((iface) => `<option value="${escapeHtml(iface)}" ${iface === zone.interface ? 'selected' : ''}>${escapeHtml(interfaceLabel(iface, interfaceDetails))}</option>`).join('')}
                 </select>
             </label>
             <label class="field">
                 <span>Latency offset</span>
                 <input id="advanced-zone-latency" type="number" min="-0.25" max="0.25" step="0.01" value="${escapeHtml(zone.latency_offset ?? 0)}">
             </label>
+            <label class="field">
+                <span>Static IP</span>
+                <input id="advanced-zone-static-ip" type="text" placeholder="DHCP" value="${escapeHtml(zone.static_ip || '')}">
+            </label>
+            <label class="field">
+                <span>Gateway</span>
+                <input id="advanced-zone-static-gateway" type="text" placeholder="Same as host" value="${escapeHtml(zone.static_gateway || '')}">
+            </label>
+            <label class="field">
+                <span>Extra Shairport arguments</span>
+                <textarea id="advanced-zone-shairport-args" placeholder="-vv --tolerance=88" spellcheck="false">${escapeHtml(formatShairportArgs(zone.shairport_args))}</textarea>
+            </label>
             <label class="check-field">
                 <input id="advanced-zone-autostart" type="checkbox" ${zone.auto_start ? 'checked' : ''}>
                 <span>Auto-start</span>
             </label>
+            <label class="check-field">
+                <input id="advanced-zone-restart" type="checkbox" ${zone.restart_on_failure ? 'checked' : ''}>
+                <span>Restart if Shairport or OwnTone dies</span>
+            </label>
             <button class="primary-btn" data-action="save-zone-advanced" data-zone-id="${escapeHtml(zone.zone_id)}">Save Zone</button>
+            <div class="advanced-row">
+                <div>
+                    <strong>AirPlay receiver</strong>
+                    <span>${zone.shairport_ip ? `${escapeHtml(zone.shairport_ip)}${zone.shairport_port ? `:${escapeHtml(zone.shairport_port)}` : ''} on ${escapeHtml(zone.interface)}` : 'not running'}</span>
+                </div>
+                ${zone.shairport_ip ? `<button class="small-btn" data-action="copy-receiver-ip" data-ip="${escapeHtml(zone.shairport_ip)}">Copy</button>` : '<span></span>'}
+            </div>
             <div class="advanced-row">
                 <div>
                     <strong>OwnTone</strong>
@@ -433,6 +605,7 @@ async function onDrawerClick(event) {
     const tab = event.target.closest('[data-drawer-tab]');
     if (tab) {
         state.activeDrawerTab = tab.dataset.drawerTab;
+        rememberZoneDrawer();
         renderZoneDrawer();
         return;
     }
@@ -444,7 +617,11 @@ async function onDrawerClick(event) {
         if (action === 'save-binding') await saveBinding(button.dataset.zoneId);
         if (action === 'clear-binding') await clearBinding(button.dataset.zoneId);
         if (action === 'save-speakers') await saveSpeakers(button.dataset.zoneId);
+        if (action === 'save-speaker-groups') await saveSpeakerGroups(button.dataset.zoneId);
+        if (action === 'speaker-reconnect') await reconnectSpeaker(button);
+        if (action === 'speaker-password') await setSpeakerPassword(button);
         if (action === 'save-zone-advanced') await saveZoneAdvanced(button.dataset.zoneId);
+        if (action === 'copy-receiver-ip') await copyText(button.dataset.ip);
         if (action === 'delete-zone') await deleteZone(button.dataset.zoneId);
     } catch (error) {
         showError(error);
@@ -491,20 +668,76 @@ async function saveSpeakers(zoneId) {
     await loadDashboard({ quiet: true });
 }
 
+async function saveSpeakerGroups(zoneId) {
+    const groups = [...els.drawerSpeakers.querySelectorAll('[data-speaker-group]')]
+        .filter((input) => input.checked)
+        .map((input) => input.dataset.speakerGroup);
+    const result = await Api.updateZone(zoneId, { speaker_groups: groups });
+    showToast(result.restarted ? 'Groups saved; restarting zone' : 'Groups saved');
+    await loadDashboard({ quiet: true });
+}
+
+async function reconnectSpeaker(button) {
+    button.disabled = true;
+    try {
+        await Api.reconnectSpeaker(button.dataset.zoneId, button.dataset.speakerId);
+        showToast('Reconnecting speaker');
+    } finally {
+        button.disabled = false;
+    }
+}
+
+function onSpeakerReconnected({ ok, error, speaker_name: speakerName } = {}) {
+    if (ok) {
+        showToast(`${speakerName || 'Speaker'} reconnected`);
+    } else {
+        showError(new Error(`${speakerName || 'Speaker'}: ${error}`));
+    }
+    refreshSoon();
+}
+
+async function setSpeakerPassword(button) {
+    const password = window.prompt(`AirPlay password for ${button.dataset.speakerName} (leave empty to clear)`);
+    if (password === null) return;
+    const result = await Api.setSpeakerPassword(button.dataset.zoneId, button.dataset.speakerId, password);
+    showToast(result.restart_required ? 'Password saved; restart the zone to use it' : 'Password saved');
+    await loadDashboard({ quiet: true });
+}
+
 async function saveZoneAdvanced(zoneId) {
     await Api.updateZone(zoneId, {
         name: document.getElementById('advanced-zone-name')?.value?.trim(),
         interface: document.getElementById('advanced-zone-interface')?.value,
         latency_offset: Number(document.getElementById('advanced-zone-latency')?.value),
+        static_ip: document.getElementById('advanced-zone-static-ip')?.value?.trim(),
+        static_gateway: document.getElementById('advanced-zone-static-gateway')?.value?.trim(),
+        shairport_args: document.getElementById('advanced-zone-shairport-args')?.value ?? '',
         auto_start: document.getElementById('advanced-zone-autostart')?.checked,
+        restart_on_failure: document.getElementById('advanced-zone-restart')?.checked,
     });
     showToast('Zone saved');
     await loadDashboard({ quiet: true });
 }
 
+async function copyText(text) {
+    // The clipboard API only exists on secure origins; plain-http LAN access falls back to showing it.
+    if (!navigator.clipboard) {
+        showToast(text);
+        return;
+    }
+    await navigator.clipboard.writeText(text);
+    showToast(`Copied ${text}`);
+}
+
 async function deleteZone(zoneId) {
     if (!window.confirm('Delete this Shiri zone?')) return;
-    await Api.deleteZone(zoneId);
+    try {
+        await Api.deleteZone(zoneId);
+    } catch (error) {
+        if (!(error instanceof ApiError) || error.status !== 409) throw error;
+        if (!window.confirm(`${error.message}. Delete anyway?`)) return;
+        await Api.deleteZone(zoneId, { force: true });
+    }
     showToast('Zone deleted');
     closeZoneDrawer();
     await loadDashboard({ quiet: true });
@@ -524,14 +757,21 @@ function closeSettings() {
 async function renderSettings() {
     const dashboard = state.dashboard || await Api.dashboard();
     state.dashboard = dashboard;
+    els.settingsInterfaceAllowlist.value = (dashboard.settings?.interface_allowlist || []).join(', ');
+    els.settingsSpeakerGroups.value = formatSpeakerGroups(dashboard.settings?.speaker_groups || []);
     await renderInterfaceOptions();
-    els.settingsZones.innerHTML = (dashboard.zones || []).map((zone) => `
+    const zones = dashboard.zones || [];
+    els.settingsZones.innerHTML = zones.map((zone, index) => `
         <div class="settings-row">
             <div>
                 <strong>${escapeHtml(zoneLabel(zone))}</strong>
                 <span>${escapeHtml(bindingText(zone))} / ${escapeHtml(zone.status)} / ${escapeHtml(zone.interface || 'no interface')}</span>
             </div>
-            <button class="small-btn" type="button" data-settings-zone="${escapeHtml(zone.zone_id)}">Open</button>
+            <div class="zone-order">
+                <button class="small-btn" type="button" data-move-zone="${escapeHtml(zone.zone_id)}" data-offset="-1" title="Move up" ${index === 0 ? 'disabled' : ''}>Up</button>
+                <button class="small-btn" type="button" data-move-zone="${escapeHtml(zone.zone_id)}" data-offset="1" title="Move down" ${index === zones.length - 1 ? 'disabled' : ''}>Down</button>
+                <button class="small-btn" type="button" data-settings-zone="${escapeHtml(zone.zone_id)}">Open</button>
+            </div>
         </div>
     `).join('') || '<div class="empty-state">No zones</div>';
     els.settingsZones.querySelectorAll('[data-settings-zone]').forEach((button) => {
@@ -540,17 +780,68 @@ async function renderSettings() {
             openZoneDrawer(button.dataset.settingsZone);
         });
     });
+    els.settingsZones.querySelectorAll('[data-move-zone]').forEach((button) => {
+        button.addEventListener('click', () => moveZone(button.dataset.moveZone, Number(button.dataset.offset)));
+    });
+}
+
+async function moveZone(zoneId, offset) {
+    const order = (state.dashboard?.zones || []).map((zone) => zone.zone_id);
+    const from = order.indexOf(zoneId);
+    const to = from + offset;
+    if (from < 0 || to < 0 || to >= order.length) return;
+    [order[from], order[to]] = [order[to], order[from]];
+    try {
+        await Api.reorderZones(order);
+        await loadDashboard({ quiet: true });
+        await renderSettings();
+    } catch (error) {
+        showError(error);
+    }
+}
+
+const CHECK_BADGES = { pass: 'running', warn: 'starting', fail: 'error' };
+
+async function renderSystemChecks() {
+    els.runSystemChecks.disabled = true;
+    try {
+        const data = await Api.systemChecks();
+        els.systemChecks.innerHTML = (data.checks || []).map((check) => `
+            <div class="settings-row">
+                <div>
+                    <strong>${escapeHtml(check.name)}</strong>
+                    <span>${escapeHtml(check.detail)}${check.fix_hint ? ` / ${escapeHtml(check.fix_hint)}` : ''}</span>
+                </div>
+                <span class="state-badge ${CHECK_BADGES[check.status] || ''}">${escapeHtml(check.status)}</span>
+            </div>
+        `).join('') || '<div class="empty-state">No checks</div>';
+    } catch (error) {
+        showError(error);
+    } finally {
+        els.runSystemChecks.disabled = false;
+    }
 }
 
 async function renderInterfaceOptions() {
     const data = await Api.interfaces();
     const interfaces = data.interfaces || [];
-    els.newZoneInterface.innerHTML = interfaces.map((iface) => `<option value="${escapeHtml(iface)}">${escapeHtml(iface)}</option>`).join('');
+    const details = data.details || [];
+    els.newZoneInterface.innerHTML = interfaces.map((iface) => `<option value="${escapeHtml(iface)}">${escapeHtml(interfaceLabel(iface, details))}</option>`).join('');
 }
 
 async function onSaveSettings(event) {
     event.preventDefault();
-    showToast('Settings saved');
+    try {
+        await Api.saveSettings({
+            interface_allowlist: els.settingsInterfaceAllowlist.value.split(',').map((name) => name.trim()).filter(Boolean),
+            speaker_groups: parseSpeakerGroups(els.settingsSpeakerGroups.value),
+        });
+        showToast('Settings saved');
+        await loadDashboard({ quiet: true });
+        await renderSettings();
+    } catch (error) {
+        showError(error);
+    }
 }
 
 async function onCreateZone(event) {
@@ -651,6 +942,9 @@ function connectSocket() {
     state.socket.on('connect', () => subscribeLogs(state.diagnosticsOpen));
     state.socket.on('zone_status', () => refreshSoon());
     state.socket.on('zone_deleted', () => refreshSoon());
+    state.socket.on('start_all_finished', onStartAllFinished);
+    state.socket.on('stop_all_finished', onStopAllFinished);
+    state.socket.on('speaker_reconnected', onSpeakerReconnected);
     state.socket.on('zone_log', appendLogEntry);
 }
 
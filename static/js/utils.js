@@ -28,6 +28,56 @@ export function selectedSpeakerText(speakers = []) {
     return items.map((speaker) => speaker.name || speaker.id || 'Speaker').join(', ');
 }
 
+export function nowPlayingText(nowPlaying) {
+    if (!nowPlaying || nowPlaying.state === 'stopped' || !nowPlaying.title) return '';
+    const line = [nowPlaying.title, nowPlaying.artist].filter(Boolean).join(' - ');
+    return nowPlaying.state === 'paused' ? `${line} (paused)` : line;
+}
+
+export function zoneMatchesFilter(zone, query) {
+    const needle = String(query || '').trim().toLowerCase();
+    if (!needle) return true;
+    return [zone.zone_name, zone.zone_id, zone.lionos_room_id, zone.lionos_room_name, zone.interface]
+        .some((value) => String(value || '').toLowerCase().includes(needle));
+}
+
+export function interfaceLabel(name, details = []) {
+    const info = details.find((item) => item.name === name);
+    if (!info) return name;
+    const notes = [];
+    if (!info.up) notes.push('down');
+    if (info.wireless) notes.push('wireless, macvlan unsupported');
+    const mac = info.mac ? ` ${info.mac}` : '';
+    return notes.length ? `${name}${mac} (${notes.join(', ')})` : `${name}${mac}`;
+}
+
+// Speaker groups are edited as "Group: Speaker, Speaker" lines.
+export function formatSpeakerGroups(groups = []) {
+    return groups.map((group) => `${group.name}: ${group.speakers.join(', ')}`).join('\n');
+}
+
+export function parseSpeakerGroups(text) {
+    return String(text || '').split('\n')
+        .map((line) => line.trim())
+        .filter(Boolean)
+        .map((line) => {
+            const split = line.indexOf(':');
+            const name = split < 0 ? line : line.slice(0, split);
+            const speakers = split < 0 ? [] : line.slice(split + 1).split(',');
+            return {
+                name: name.trim(),
+                speakers: speakers.map((speaker) => speaker.trim()).filter(Boolean),
+            };
+        });
+}
+
+// Extra Shairport arguments are edited as shell words; the server splits them.
+export function formatShairportArgs(args = []) {
+    return args
+        .map((arg) => (/^[\w@%+=:,./-]+$/.test(arg) ? arg : `'${arg.replaceAll("'", `'"'"'`)}'`))
+        .join('\n');
+}
+
 export function bindingText(zone) {
     if (!zone?.lionos_room_id) return 'No LionOS binding';
     return zone.lionos_room_name
@@ -35,6 +85,30 @@ export function bindingText(zone) {
         : zone.lionos_room_id;
 }
 
+const PREFS_KEY = 'shiri.ui';
+
+// Browser-local UI preferences. Storage can be unavailable (private mode,
+// blocked cookies), in which case preferences just don't persist.
+export function readPref(key, fallback = null) {
+    try {
+        const prefs = JSON.parse(window.localStorage.getItem(PREFS_KEY) || '{}');
+        return prefs[key] ?? fallback;
+    } catch {
+        return fallback;
+    }
+}
+
+export function writePref(key, value) {
+    try {
+        const prefs = JSON.parse(window.localStorage.getItem(PREFS_KEY) || '{}');
+        if (value === null || value === undefined) delete prefs[key];
+        else prefs[key] = value;
+        window.localStorage.setItem(PREFS_KEY, JSON.stringify(prefs));
+    } catch {
+        // Ignore; see readPref.
+    }
+}
+
 export function debounce(fn, delay = 350) {
     let timer = null;
     return (...args) => {
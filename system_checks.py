@@ -0,0 +1,335 @@
+"""
+Host readiness checks for Shiri.
+
+Each check returns {name, status, detail, fix_hint} with status pass, warn or
+fail. A fail means zones cannot start until it is fixed; warn means some zones
+or features may misbehave.
+"""
+
+import logging
+import os
+import re
+from concurrent.futures import ThreadPoolExecutor
+
+from config import LOOPBACK_LOCK_DIR, LOOPBACK_SUBDEVICE_COUNT, zone_name_key
+from zone_lifecycle import DHCLIENT_SCRIPT_MARKER, DHCLIENT_SCRIPT_PATH, _run, missing_binaries
+
+log = logging.getLogger("shiri.checks")
+
+PASS = "pass"
+WARN = "warn"
+FAIL = "fail"
+
+# Capability bits from linux/capability.h that a zone start relies on.
+REQUIRED_CAPABILITIES = {
+    "CAP_NET_ADMIN": (12, "create macvlans, veths and namespace routes"),
+    "CAP_SYS_ADMIN": (21, "create network namespaces and bind-mount avahi/dbus runtime dirs"),
+    "CAP_SYS_NICE": (23, "run the mixer with realtime priority (chrt -f)"),
+}
+
+# mDNS conflict checks. Both AirPlay service types are browsed in parallel,
+# so the checks request waits at most one timeout for them.
+HOST_AVAHI_CONFIG = "/etc/avahi/avahi-daemon.conf"
+MDNS_SERVICE_TYPES = ("_raop._tcp", "_airplay._tcp")
+MDNS_BROWSE_TIMEOUT_SECONDS = 4
+
+
+def _check(name, status, detail, fix_hint=""):
+    return {"name": name, "status": status, "detail": detail, "fix_hint": fix_hint}
+
+
+def _check_root():
+    if os.geteuid() == 0:
+        return _check("Root privileges", PASS, "Running as root")
+    return _check(
+        "Root privileges", FAIL,
+        f"Running as uid {os.geteuid()}; network namespaces and macvlans need root",
+        "Start Shiri with sudo scripts/shiri_service.sh start",
+    )
+
+
+def _effective_capabilities():
+    try:
+        with open("/proc/self/status") as f:
+            for line in f:
+                if line.startswith("CapEff:"):
+                    return int(line.split()[1], 16)
+    except (OSError, ValueError, IndexError):
+        pass
+    return None
+
+
+def _check_capabilities():
+    effective = _effective_capabilities()
+    if effective is None:
+        return _check("Capabilities", WARN, "Could not read effective capabilities from /proc/self/status")
+    missing = [
+        f"{name} ({reason})"
+        for name, (bit, reason) in REQUIRED_CAPABILITIES.items()
+        if not effective & (1 << bit)
+    ]
+    if not missing:
+        return _check("Capabilities", PASS, ", ".join(REQUIRED_CAPABILITIES))
+    return _check(
+        "Capabilities", FAIL,
+        f"Missing {'; '.join(missing)}",
+        "Run Shiri as real root; in a container grant --cap-add NET_ADMIN --cap-add SYS_ADMIN --cap-add SYS_NICE",
+    )
+
+
+def _check_macvlan():
+    if os.path.isdir("/sys/module/macvlan"):
+        return _check("macvlan driver", PASS, "macvlan module is loaded")
+    try:
+        result = _run(["modprobe", "--dry-run", "macvlan"], timeout=5)
+    except OSError:
+        result = None
+    if result is not None and result.returncode == 0:
+        return _check("macvlan driver", PASS, "macvlan module is available and loads on first use")
+    return _check(
+        "macvlan driver", FAIL,
+        "macvlan is neither loaded nor loadable; zone namespaces cannot reach the LAN",
+        "Install the kernel's extra modules (e.g. linux-modules-extra-$(uname -r)) and run modprobe macvlan",
+    )
+
+
+def _check_binaries():
+    missing = missing_binaries()
+    if not missing:
+        return _check("Required programs", PASS, "All zone programs are installed")
+    return _check(
+        "Required programs", FAIL,
+        f"Not installed: {', '.join(missing)}",
+        "Install the missing packages, or build shairport-sync/owntone/nqptp/airptpd into /usr/local",
+    )
+
+
+def _check_alsa_loopback(zone_manager):
+    if zone_manager._alsa_ready or os.path.isdir("/proc/asound/Loopback"):
+        return _check("ALSA loopback", PASS, "snd-aloop is loaded")
+    return _check(
+        "ALSA loopback", FAIL,
+        "The snd-aloop Loopback card is not present",
+        "modprobe snd-aloop pcm_substreams=16",
+    )
+
+
+def _check_loopback_capacity():
+    try:
+        in_use = sum(1 for name in os.listdir(LOOPBACK_LOCK_DIR) if name.endswith(".lock"))
+    except FileNotFoundError:
+        in_use = 0
+    detail = f"{in_use}/{LOOPBACK_SUBDEVICE_COUNT} loopback subdevices allocated"
+    if in_use >= LOOPBACK_SUBDEVICE_COUNT:
+        return _check(
+            "Loopback capacity", WARN, detail,
+            f"Stop a zone, or remove stale locks in {LOOPBACK_LOCK_DIR} if no zone is running",
+        )
+    return _check("Loopback capacity", PASS, detail)
+
+
+def _check_config_writable(config_path):
+    # The store creates missing directories on save, so test the nearest
+    # existing ancestor when the config does not exist yet.
+    target = config_path
+    while not os.path.exists(target) and os.path.dirname(target) != target:
+        target = os.path.dirname(target)
+    if os.access(target, os.W_OK):
+        return _check("Config file", PASS, f"{config_path} is writable")
+    return _check(
+        "Config file", FAIL,
+        f"Cannot write {config_path}; zone changes will not be saved",
+        "Fix permissions, or point SHIRI_CONFIG at a writable path",
+    )
+
+
+def _check_dhclient_script():
+    try:
+        with open(DHCLIENT_SCRIPT_PATH) as f:
+            existing = f.read()
+    except OSError:
+        return _check("dhclient hook", PASS, f"{DHCLIENT_SCRIPT_PATH} will be installed on first start")
+    if DHCLIENT_SCRIPT_MARKER in existing:
+        return _check("dhclient hook", PASS, "Shiri's namespace dhclient script is installed")
+    return _check(
+        "dhclient hook", FAIL,
+        f"{DHCLIENT_SCRIPT_PATH} is not Shiri's script and will not be overwritten",
+        f"Move {DHCLIENT_SCRIPT_PATH} aside so Shiri can install its namespace-only hook",
+    )
+
+
+def _check_interfaces(zone_manager):
+    details = {item["name"]: item for item in zone_manager.get_network_interface_details()}
+    if not details:
+        return [_check(
+            "Network interfaces", FAIL, "No usable network interface found",
+            "Connect a wired NIC, or add it under Settings > Extra interfaces",
+        )]
+    checks = []
+    for zone in zone_manager.list_zones():
+        name = f"Interface for {zone.display_name}"
+        info = details.get(zone.interface)
+        if not zone.interface:
+            checks.append(_check(name, FAIL, "No interface configured", "Pick an interface in the zone's Advanced tab"))
+        elif not info:
+            checks.append(_check(name, FAIL, f"{zone.interface} does not exist",
+                                 "Pick an existing interface in the zone's Advanced tab"))
+        elif info["wireless"]:
+            checks.append(_check(name, WARN, f"{zone.interface} is wireless; macvlan usually gets no traffic over Wi-Fi",
+                                 "Use a wired interface"))
+        elif not info["up"]:
+            checks.append(_check(name, WARN, f"{zone.interface} is down", f"ip link set {zone.interface} up"))
+        else:
+            checks.append(_check(name, PASS, f"{zone.interface} is up"))
+    if not checks:
+        checks.append(_check("Network interfaces", PASS, f"{len(details)} interface(s) available"))
+    return checks
+
+
+def _host_processes(names):
+    """Return [(pid, name)] for processes in the host network namespace.
+
+    Shiri's own Shairport and Avahi run in zone namespaces, so anything with
+    these names in Shiri's namespace was started by something else.
+    """
+    try:
+        host_ns = os.readlink("/proc/self/ns/net")
+    except OSError:
+        return []
+    found = []
+    for pid in os.listdir("/proc"):
+        if not pid.isdigit():
+            continue
+        try:
+            with open(f"/proc/{pid}/comm") as f:
+                name = f.read().strip()
+            if name in names and os.readlink(f"/proc/{pid}/ns/net") == host_ns:
+                found.append((int(pid), name))
+        except OSError:
+            continue
+    return found
+
+
+def _avahi_reflector_enabled():
+    try:
+        with open(HOST_AVAHI_CONFIG) as f:
+            return any(
+                line.split("#", 1)[0].replace(" ", "").lower() == "enable-reflector=yes"
+                for line in f
+            )
+    except OSError:
+        return False
+
+
+def _check_host_mdns():
+    processes = _host_processes({"avahi-daemon", "shairport-sync"})
+    shairports = sorted(pid for pid, name in processes if name == "shairport-sync")
+    avahi = any(name == "avahi-daemon" for _, name in processes)
+    if shairports:
+        return _check(
+            "Host AirPlay receivers", WARN,
+            f"shairport-sync is running outside Shiri (pid {', '.join(map(str, shairports))}); "
+            "senders may see it next to or instead of a zone",
+            "Stop and disable the host shairport-sync service (systemctl disable --now shairport-sync)",
+        )
+    if avahi and _avahi_reflector_enabled():
+        return _check(
+            "Host AirPlay receivers", WARN,
+            f"The host avahi-daemon has enable-reflector=yes in {HOST_AVAHI_CONFIG}; "
+            "it can re-announce zone receivers and senders see duplicates",
+            f"Set enable-reflector=no in {HOST_AVAHI_CONFIG} and restart avahi-daemon",
+        )
+    if avahi:
+        return _check("Host AirPlay receivers", PASS, "Host avahi-daemon is running without the reflector")
+    return _check("Host AirPlay receivers", PASS, "No host avahi-daemon or shairport-sync running")
+
+
+def _unescape_dns_sd(value):
+    # avahi-browse -p escapes dots and backslashes with a backslash and other bytes
+    # as \DDD (decimal); the DDD bytes are UTF-8.
+    raw = re.sub(
+        rb"\\(\d{3}|.)",
+        lambda m: bytes([int(m.group(1))]) if len(m.group(1)) == 3 else m.group(1),
+        value.encode("utf-8"),
+    )
+    return raw.decode("utf-8", errors="replace")
+
+
+def _browse_advertisements(service_type):
+    """Return [(interface, name, address)] for resolved services, or None if browsing failed."""
+    try:
+        result = _run(["avahi-browse", "-rpt", service_type], timeout=MDNS_BROWSE_TIMEOUT_SECONDS)
+    except OSError:
+        return None
+    if result.returncode != 0:
+        return None
+    found = []
+    for line in result.stdout.splitlines():
+        fields = line.split(";")
+        if len(fields) < 8 or fields[0] != "=" or fields[2] != "IPv4":
+            continue
+        name = _unescape_dns_sd(fields[3])
+        if service_type == "_raop._tcp":
+            # RAOP names are "<device id>@<receiver name>".
+            name = name.split("@", 1)[-1]
+        found.append((fields[1], name, fields[7]))
+    return found
+
+
+def _check_mdns_duplicates(zone_manager):
+    zones = zone_manager.list_zones()
+    if not zones:
+        return [_check("AirPlay name conflicts", PASS, "No zones configured")]
+    with ThreadPoolExecutor(max_workers=len(MDNS_SERVICE_TYPES)) as pool:
+        results = list(pool.map(_browse_advertisements, MDNS_SERVICE_TYPES))
+    advertisements = []
+    for found in results:
+        if found is None:
+            return [_check(
+                "AirPlay name conflicts", WARN,
+                "Could not browse AirPlay advertisements; duplicate zone names on the LAN are not checked",
+                "Install avahi-utils and run avahi-daemon on the host",
+            )]
+        advertisements.extend(found)
+    checks = []
+    for zone in zones:
+        name = f"AirPlay name {zone.display_name}"
+        # Macvlan children are invisible to the parent interface, so the
+        # zone's own receiver is normally absent here; skip it if it is not.
+        others = sorted({
+            address for iface, advertised, address in advertisements
+            if iface == zone.interface
+            and zone_name_key(advertised) == zone_name_key(zone.display_name)
+            and address != zone.shairport_ip
+        })
+        if others:
+            checks.append(_check(
+                name, WARN,
+                f"Another AirPlay receiver named '{zone.display_name}' is advertised on {zone.interface} "
+                f"by {', '.join(others)}; senders may pick it instead of the zone",
+                "Rename the zone or the other device, or stop the other receiver",
+            ))
+        else:
+            checks.append(_check(name, PASS, f"No other receiver uses this name on {zone.interface}"))
+    return checks
+
+
+def run_checks(zone_manager):
+    """Run every check. Returns {"ok": bool, "checks": [...]}; ok is False if any check failed."""
+    checks = [
+        _check_root(),
+        _check_capabilities(),
+        _check_macvlan(),
+        _check_binaries(),
+        _check_alsa_loopback(zone_manager),
+        _check_loopback_capacity(),
+        _check_config_writable(zone_manager.config_store.path),
+        _check_dhclient_script(),
+        _check_host_mdns(),
+    ]
+    checks.extend(_check_interfaces(zone_manager))
+    checks.extend(_check_mdns_duplicates(zone_manager))
+    for item in checks:
+        if item["status"] != PASS:
+            log.info("System check %s: %s (%s)", item["name"], item["status"], item["detail"])
+    return {"ok": not any(item["status"] == FAIL for item in checks), "checks": checks}
@@ -0,0 +1,80 @@
+import json
+import os
+import tempfile
+import unittest
+
+from config import CONFIG_VERSION, ConfigStore, ConfigVersionError, migrate_config
+
+
+# (description, zone config before, zone config after migrating to CONFIG_VERSION)
+ZONE_CASES = [
+    (
+        "legacy room keys renamed",
+        {"name": "Kitchen", "room_id": "kitchen", "room_name": "Kitchen", "default_room": 1},
+        {"name": "Kitchen", "lionos_room_id": "kitchen", "lionos_room_name": "Kitchen",
+         "default_lionos_room": True},
+    ),
+    (
+        "existing lionos keys win over legacy ones",
+        {"name": "Den", "room_id": "old", "lionos_room_id": "den"},
+        {"name": "Den", "lionos_room_id": "den"},
+    ),
+    (
+        "legacy network keys dropped",
+        {"name": "Patio", "interface": "eth0", "network_mode": "macvlan",
+         "netns_name": "shiri_patio", "macvlan_if": "mv0"},
+        {"name": "Patio", "interface": "eth0"},
+    ),
+    (
+        "current config untouched",
+        {"name": "Office", "interface": "eth1", "auto_start": True},
+        {"name": "Office", "interface": "eth1", "auto_start": True},
+    ),
+]
+
+
+class MigrateConfigTest(unittest.TestCase):
+    def test_v0_zone_cases(self):
+        for description, before, after in ZONE_CASES:
+            with self.subTest(description):
+                data = {"zones": {"zone_a": dict(before)}}
+                self.assertTrue(migrate_config(data))
+                self.assertEqual(data["version"], CONFIG_VERSION)
+                self.assertEqual(data["zones"]["zone_a"], after)
+
+    def test_current_version_is_unchanged(self):
+        data = {"version": CONFIG_VERSION, "zones": {"zone_a": {"name": "Kitchen"}}}
+        self.assertFalse(migrate_config(data))
+        self.assertEqual(data["zones"]["zone_a"], {"name": "Kitchen"})
+
+    def test_newer_version_is_rejected(self):
+        with self.assertRaises(ConfigVersionError):
+            migrate_config({"version": CONFIG_VERSION + 1, "zones": {}})
+
+
+class ConfigStoreMigrationTest(unittest.TestCase):
+    def setUp(self):
+        tmp = tempfile.TemporaryDirectory()
+        self.addCleanup(tmp.cleanup)
+        self.path = os.path.join(tmp.name, "config.json")
+
+    def _write(self, data):
+        with open(self.path, "w") as f:
+            json.dump(data, f)
+
+    def test_v0_config_is_rewritten_on_load(self):
+        self._write({"zones": {"zone_a": {"name": "Kitchen", "room_id": "kitchen", "netns_name": "shiri_a"}}})
+        ConfigStore(self.path)
+        with open(self.path) as f:
+            saved = json.load(f)
+        self.assertEqual(saved["version"], CONFIG_VERSION)
+        self.assertEqual(saved["zones"]["zone_a"], {"name": "Kitchen", "lionos_room_id": "kitchen"})
+
+    def test_newer_config_is_not_loaded(self):
+        self._write({"version": CONFIG_VERSION + 1, "zones": {}})
+        with self.assertRaises(ConfigVersionError):
+            ConfigStore(self.path)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,38 @@
+import os
+import tempfile
+import unittest
+from unittest import mock
+
+from config import DEFAULT_CONFIG_PATH, ConfigStore, default_config_path
+
+
+class ConfigPathTest(unittest.TestCase):
+    def setUp(self):
+        tmp = tempfile.TemporaryDirectory()
+        self.addCleanup(tmp.cleanup)
+        self.tmp = tmp.name
+
+    def test_default_path_without_override(self):
+        with mock.patch.dict(os.environ, {}, clear=False):
+            os.environ.pop("SHIRI_CONFIG", None)
+            self.assertEqual(default_config_path(), DEFAULT_CONFIG_PATH)
+
+    def test_shiri_config_override_is_honored(self):
+        path = os.path.join(self.tmp, "shiri.json")
+        with mock.patch.dict(os.environ, {"SHIRI_CONFIG": path}):
+            store = ConfigStore()
+        self.assertEqual(store.path, path)
+        store.save_zone("zone_a", {"name": "Kitchen"})
+        self.assertTrue(os.path.exists(path))
+
+    def test_missing_parent_directory_is_created(self):
+        path = os.path.join(self.tmp, "etc", "shiri", "config.json")
+        with mock.patch.dict(os.environ, {"SHIRI_CONFIG": path}):
+            store = ConfigStore()
+        store.save_zone("zone_a", {"name": "Kitchen"})
+        self.assertTrue(os.path.isfile(path))
+        self.assertEqual(ConfigStore(path).get_zone("zone_a")["name"], "Kitchen")
+
+
+if __name__ == "__main__":
+    unittest.main()
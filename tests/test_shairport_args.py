@@ -0,0 +1,32 @@
+import unittest
+
+from zone import ShairportArgsError, normalize_shairport_args
+
+
+class NormalizeShairportArgsTest(unittest.TestCase):
+    def test_allowlisted_options_pass(self):
+        self.assertEqual(
+            normalize_shairport_args("-vv\n--tolerance=88\n-S soxr"),
+            ["-vv", "--tolerance=88", "-S", "soxr"],
+        )
+        self.assertEqual(normalize_shairport_args(["--stuffing", "basic", "-u"]), ["--stuffing", "basic", "-u"])
+        self.assertEqual(normalize_shairport_args(""), [])
+
+    def test_program_hooks_rejected(self):
+        for raw in ("-B /bin/sh", "--on-start=/bin/sh", "--on-start /bin/sh", "-E /bin/sh", "--on-stop=/bin/sh"):
+            with self.subTest(raw=raw), self.assertRaises(ShairportArgsError):
+                normalize_shairport_args(raw)
+
+    def test_output_and_metadata_options_rejected(self):
+        for raw in ("-o pipe", "--output=stdout", "--", "-M", "--metadata-pipename=/tmp/x", "-c /tmp/x.conf"):
+            with self.subTest(raw=raw), self.assertRaises(ShairportArgsError):
+                normalize_shairport_args(raw)
+
+    def test_values_are_checked(self):
+        for raw in ("--tolerance", "--tolerance=abc", "-S /bin/sh", "-S=soxr", "-v /bin/sh"):
+            with self.subTest(raw=raw), self.assertRaises(ShairportArgsError):
+                normalize_shairport_args(raw)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,70 @@
+import os
+import tempfile
+import unittest
+
+from config import ConfigStore, generate_owntone_config
+from zone import Zone, ZoneManager
+
+
+class SpeakerGroupConfigTest(unittest.TestCase):
+    def setUp(self):
+        self.tmp = tempfile.TemporaryDirectory()
+        self.addCleanup(self.tmp.cleanup)
+        store = ConfigStore(os.path.join(self.tmp.name, "config.json"))
+        store.update_settings({"speaker_groups": [
+            {"name": "Downstairs", "speakers": ["Kitchen", "Living Room"]},
+            {"name": "Outside", "speakers": ["Patio", "Kitchen"]},
+        ]})
+        self.manager = ZoneManager(store)
+
+    def _zone(self, config):
+        zone = Zone("zone_test", dict({"name": "Test"}, **config))
+        zone._grp_dir = os.path.join(self.tmp.name, "zone_test")
+        os.makedirs(os.path.join(zone._grp_dir, "config"))
+        zone.allocated_subdevice = 0
+        return zone
+
+    def test_groups_resolve_in_order_without_duplicates(self):
+        zone = self._zone({"speaker_groups": ["Downstairs", "Outside", "Gone"]})
+        with self.assertLogs("shiri.zone", "WARNING"):
+            names = self.manager._group_speaker_names(zone)
+        self.assertEqual(names, ["Kitchen", "Living Room", "Patio"])
+
+    def test_group_speakers_get_permanent_airplay_blocks(self):
+        zone = self._zone({
+            "speaker_groups": ["Outside"],
+            "speaker_names": [{"name": "Bedroom"}],
+        })
+        zone.group_speaker_names = self.manager._group_speaker_names(zone)
+        generate_owntone_config(zone)
+        with open(os.path.join(zone.grp_dir, "config", "owntone.conf")) as f:
+            content = f.read()
+        for name in ("Bedroom", "Patio", "Kitchen"):
+            block = f'airplay "{name}" {{\n\tpermanent = true\n\treconnect = true\n}}'
+            self.assertIn(block, content)
+        self.assertNotIn('airplay "Living Room"', content)
+
+    def test_non_airplay_speakers_get_no_airplay_block(self):
+        zone = self._zone({
+            "speaker_groups": ["Outside"],
+            "speaker_names": [
+                {"name": "Kitchen", "type": "Chromecast"},
+                {"name": "Local Output", "type": "ALSA"},
+                {"name": "Bedroom", "type": "AirPlay 2"},
+            ],
+            "speaker_passwords": {"Kitchen": "secret"},
+        })
+        zone.group_speaker_names = self.manager._group_speaker_names(zone)
+        zone.speaker_types = self.manager._saved_speaker_types(zone)
+        generate_owntone_config(zone)
+        with open(os.path.join(zone.grp_dir, "config", "owntone.conf")) as f:
+            content = f.read()
+        self.assertIn('airplay "Bedroom"', content)
+        self.assertIn('airplay "Patio"', content)
+        self.assertNotIn('airplay "Kitchen"', content)
+        self.assertNotIn('airplay "Local Output"', content)
+        self.assertNotIn("secret", content)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,53 @@
+import os
+import tempfile
+import unittest
+
+from config import ConfigStore
+from zone import Zone, ZoneManager
+
+
+class StartAllTest(unittest.TestCase):
+    def setUp(self):
+        tmp = tempfile.TemporaryDirectory()
+        self.addCleanup(tmp.cleanup)
+        self.manager = ZoneManager(ConfigStore(os.path.join(tmp.name, "config.json")))
+        for zone_id in ("zone_a", "zone_b", "zone_c"):
+            self.manager.zones[zone_id] = Zone(zone_id, {"name": zone_id})
+        self.started = []
+
+    def _fake_start(self, outcomes):
+        def start_zone(zone_id):
+            zone = self.manager.zones[zone_id]
+            # Each zone must have settled before the next one is started.
+            self.assertTrue(all(self.manager.zones[z].status != Zone.STATUS_STARTING for z in self.started))
+            self.started.append(zone_id)
+            zone.status = outcomes[zone_id]
+            if zone.status == Zone.STATUS_ERROR:
+                zone.error_message = "DHCP failed"
+            return True
+        self.manager.start_zone = start_zone
+
+    def test_zones_start_in_sequence_with_per_zone_results(self):
+        self._fake_start({
+            "zone_a": Zone.STATUS_RUNNING,
+            "zone_b": Zone.STATUS_ERROR,
+            "zone_c": Zone.STATUS_STARTING,
+        })
+        report = self.manager.start_all(stagger=0, wait=True, timeout=0.6)
+        self.assertEqual(self.started, ["zone_a", "zone_b", "zone_c"])
+        results = {item["zone_id"]: item for item in report["results"]}
+        self.assertEqual(results["zone_a"]["result"], "ok")
+        self.assertEqual(results["zone_b"]["result"], "error")
+        self.assertEqual(results["zone_b"]["error"], "DHCP failed")
+        self.assertEqual(results["zone_c"]["result"], "timeout")
+
+    def test_running_zones_are_skipped(self):
+        self.manager.zones["zone_b"].status = Zone.STATUS_RUNNING
+        self._fake_start({"zone_a": Zone.STATUS_RUNNING, "zone_c": Zone.STATUS_RUNNING})
+        report = self.manager.start_all(stagger=0, wait=True, timeout=1)
+        self.assertEqual(report["queued"], ["zone_a", "zone_c"])
+        self.assertEqual(report["skipped"], [{"zone_id": "zone_b", "reason": "Zone is running"}])
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -8,10 +8,12 @@ latency, player status), diagnostic monitoring, event emission, and shutdown.
 Start/stop implementation details are delegated to zone_lifecycle.py.
 """
 
+import ipaddress
 import logging
 import os
 import json
 import re
+import shlex
 import shutil
 import subprocess
 import threading
@@ -21,17 +23,23 @@ import uuid
 from config import (
     BASE_DIR,
     DEFAULT_LATENCY_OFFSET,
+    LOOPBACK_SUBDEVICE_COUNT,
     normalize_latency_offset,
     sanitize_audio_settings,
+    zone_name_key,
     MIXER_TTS_WEBRTC_SOCKET_NAME,
 )
+from tts_webrtc import _send_mixer_request
 from zone_lifecycle import (
+    SPEAKER_OUTPUT_TYPES,
     _run,
     _kill_pid,
     start_zone_thread,
     stop_zone_thread,
     cleanup_zone,
     cleanup_stale_runtime,
+    missing_binaries,
+    restart_mixer,
 )
 
 log = logging.getLogger("shiri.zone")
@@ -40,7 +48,205 @@ DEFAULT_REDUCTION_PCT = 72
 DEFAULT_DUCK_GAIN = 1.0 - (DEFAULT_REDUCTION_PCT / 100.0)
 MIN_REDUCTION_PCT = 0
 MAX_REDUCTION_PCT = 95
-LEGACY_ZONE_CONFIG_KEYS = {"network_mode", "netns_name", "macvlan_if"}
+DEFAULT_START_STAGGER_SECONDS = 3.0
+MAX_START_STAGGER_SECONDS = 60.0
+SPEAKER_RECONNECT_DELAY_SECONDS = 1.0
+# Start all waits this long for each zone (DHCP alone can take 20s) before
+# moving on to the next one.
+START_ALL_ZONE_TIMEOUT_SECONDS = 60.0
+MIXER_RESTART_BACKOFF_SECONDS = 2.0
+MIXER_RESTART_BACKOFF_MAX_SECONDS = 60.0
+# A mixer that stays up this long resets the backoff.
+MIXER_STABLE_SECONDS = 60.0
+# Zones with restart_on_failure give up after this many restarts per window.
+FAILURE_RESTART_LIMIT = 3
+FAILURE_RESTART_WINDOW_SECONDS = 600.0
+
+
+# Bridges, tunnels and container/VM plumbing, plus Shiri's own OwnTone API veth.
+VIRTUAL_INTERFACE_PREFIXES = (
+    "veth", "docker", "br-", "virbr", "vnet", "cni", "flannel", "lxc",
+    "tun", "tap", "wg", "zt", "tailscale", "otapi",
+)
+
+
+def _read_sys_value(path):
+    try:
+        with open(path) as f:
+            return f.read().strip()
+    except OSError:
+        return ""
+
+
+def _is_physical_interface(name):
+    if name.startswith(VIRTUAL_INTERFACE_PREFIXES):
+        return False
+    return os.path.exists(f"/sys/class/net/{name}/device")
+
+
+class DuplicateZoneNameError(ValueError):
+    """Another zone already uses this name (compared case-insensitively)."""
+
+    def __init__(self, name):
+        super().__init__(f"A zone named '{str(name or '').strip()}' already exists")
+        self.name = name
+
+
+class InvalidZoneNameError(ValueError):
+    """The zone name cannot be advertised as an AirPlay receiver."""
+
+
+# Shairport advertises RAOP as "<12 hex MAC>@<name>" and DNS-SD instance
+# names are capped at 63 bytes, which leaves 50 bytes for the name.
+MAX_AIRPLAY_NAME_BYTES = 50
+
+
+def normalize_airplay_name(name):
+    """Return the zone name with whitespace collapsed, or raise InvalidZoneNameError."""
+    name = " ".join(str(name or "").split())
+    if not name:
+        raise InvalidZoneNameError("Zone name is required")
+    if len(name.encode("utf-8")) > MAX_AIRPLAY_NAME_BYTES:
+        raise InvalidZoneNameError(
+            f"Zone name is too long for AirPlay (max {MAX_AIRPLAY_NAME_BYTES} bytes, "
+            f"'{name}' is {len(name.encode('utf-8'))})"
+        )
+    # The name is written into quoted Shairport and OwnTone config strings.
+    bad = sorted({ch for ch in name if ch in '"\\' or ord(ch) < 32 or ord(ch) == 127})
+    if bad:
+        shown = ", ".join(repr(ch) for ch in bad)
+        raise InvalidZoneNameError(f"Zone name cannot contain {shown}")
+    return name
+
+
+class StaticAddressError(ValueError):
+    """A zone's static receiver address is malformed or already in use."""
+
+
+def _normalize_static_address(static_ip, gateway=None):
+    """Validate a zone's static receiver address.
+
+    Returns (cidr, gateway) as strings, or (None, None) when the zone should
+    use DHCP. The gateway may be empty, in which case the parent interface's
+    default gateway is used at start.
+    """
+    static_ip = str(static_ip or "").strip()
+    gateway = str(gateway or "").strip()
+    if not static_ip:
+        return None, None
+    if "/" not in static_ip:
+        raise StaticAddressError(f"Static IP '{static_ip}' needs a prefix length, e.g. {static_ip}/24")
+    try:
+        address = ipaddress.IPv4Interface(static_ip)
+    except ValueError:
+        raise StaticAddressError(f"'{static_ip}' is not a valid IPv4 address/prefix")
+    network = address.network
+    if network.prefixlen < 31 and address.ip in (network.network_address, network.broadcast_address):
+        raise StaticAddressError(f"{address.ip} is the network or broadcast address of {network}")
+    if gateway:
+        try:
+            gateway_ip = ipaddress.IPv4Address(gateway)
+        except ValueError:
+            raise StaticAddressError(f"Gateway '{gateway}' is not a valid IPv4 address")
+        if gateway_ip not in network:
+            raise StaticAddressError(f"Gateway {gateway_ip} is not in {network}")
+        if gateway_ip == address.ip:
+            raise StaticAddressError(f"Gateway {gateway_ip} is the zone's own address")
+    return str(address), gateway or None
+
+
+class SpeakerGroupError(ValueError):
+    """A speaker group definition or reference is invalid."""
+
+
+def normalize_speaker_groups(raw):
+    """Validate the speaker_groups setting: [{"name", "speakers": [speaker names]}].
+
+    Speakers are stored by OwnTone output name, the same key speaker restore
+    matches on, so a group works in every zone that can see those speakers.
+    """
+    if raw is None:
+        return []
+    if not isinstance(raw, list):
+        raise SpeakerGroupError("speaker_groups must be a list")
+    groups = []
+    seen = set()
+    for item in raw:
+        if not isinstance(item, dict):
+            raise SpeakerGroupError("Each speaker group needs a name and a speaker list")
+        name = " ".join(str(item.get("name") or "").split())
+        if not name:
+            raise SpeakerGroupError("Speaker group names cannot be empty")
+        if name.casefold() in seen:
+            raise SpeakerGroupError(f"Speaker group '{name}' is defined twice")
+        speakers = item.get("speakers") or []
+        if isinstance(speakers, str):
+            speakers = speakers.split(",")
+        if not isinstance(speakers, list):
+            raise SpeakerGroupError(f"Speakers for group '{name}' must be a list of names")
+        speakers = list(dict.fromkeys(str(speaker).strip() for speaker in speakers if str(speaker).strip()))
+        if not speakers:
+            raise SpeakerGroupError(f"Speaker group '{name}' has no speakers")
+        seen.add(name.casefold())
+        groups.append({"name": name, "speakers": speakers})
+    return groups
+
+
+class ShairportArgsError(ValueError):
+    """A zone's extra shairport-sync arguments include an option Shiri does not allow."""
+
+
+MAX_SHAIRPORT_ARGS = 32
+# Only these options may be passed through. Shairport runs as root, and
+# options such as -B/--on-start run programs or, like -o and -M, move its
+# output and metadata away from the pipes Shiri reads, so anything not listed
+# here is rejected.
+SHAIRPORT_FLAG_OPTIONS = {"-v", "-vv", "-vvv", "-u", "--use-stderr", "--logOutputLevel"}
+SHAIRPORT_VALUE_OPTIONS = {
+    "--tolerance": re.compile(r"\d{1,5}"),
+    "-S": re.compile(r"basic|soxr|auto"),
+    "--stuffing": re.compile(r"basic|soxr|auto"),
+}
+
+
+def normalize_shairport_args(raw):
+    """Validate a zone's extra shairport-sync arguments against the allowlist.
+
+    Accepts a list of arguments or a shell-quoted string (one option per line
+    in the UI) and returns the argument list. Value options may be written as
+    "--tolerance=88" or as two words.
+    """
+    if raw is None:
+        return []
+    if isinstance(raw, str):
+        try:
+            raw = shlex.split(raw)
+        except ValueError as e:
+            raise ShairportArgsError(f"Could not parse Shairport arguments: {e}")
+    if not isinstance(raw, list):
+        raise ShairportArgsError("shairport_args must be a list of arguments")
+    args = [str(arg).strip() for arg in raw if str(arg).strip()]
+    if len(args) > MAX_SHAIRPORT_ARGS:
+        raise ShairportArgsError(f"At most {MAX_SHAIRPORT_ARGS} Shairport arguments are allowed")
+    allowed = ", ".join(sorted(SHAIRPORT_FLAG_OPTIONS | set(SHAIRPORT_VALUE_OPTIONS)))
+    index = 0
+    while index < len(args):
+        arg = args[index]
+        index += 1
+        if arg in SHAIRPORT_FLAG_OPTIONS:
+            continue
+        option, has_value, value = arg.partition("=")
+        pattern = SHAIRPORT_VALUE_OPTIONS.get(option)
+        if pattern is None or (has_value and not option.startswith("--")):
+            raise ShairportArgsError(f"Shairport option {arg!r} is not allowed; allowed options are {allowed}")
+        if not has_value:
+            if index >= len(args):
+                raise ShairportArgsError(f"Shairport option {option} needs a value")
+            value = args[index]
+            index += 1
+        if not pattern.fullmatch(value):
+            raise ShairportArgsError(f"{value!r} is not a valid value for Shairport option {option}")
+    return args
 
 
 def _slugify_lionos_room_id(value):
@@ -98,17 +304,6 @@ def _normalize_tts_policy(raw=None):
 
 def _sanitize_zone_config(raw):
     config = sanitize_audio_settings(raw)
-    for key in LEGACY_ZONE_CONFIG_KEYS:
-        config.pop(key, None)
-    legacy_room_id = config.pop("room_id", None)
-    legacy_room_name = config.pop("room_name", None)
-    legacy_default_room = config.pop("default_room", None)
-    if legacy_room_id and "lionos_room_id" not in config:
-        config["lionos_room_id"] = legacy_room_id
-    if legacy_room_name and "lionos_room_name" not in config:
-        config["lionos_room_name"] = legacy_room_name
-    if legacy_default_room is not None and "default_lionos_room" not in config:
-        config["default_lionos_room"] = bool(legacy_default_room)
     if "lionos_room_id" in config:
         room_id = _slugify_lionos_room_id(config.get("lionos_room_id"))
         if room_id == "default":
@@ -122,6 +317,15 @@ def _sanitize_zone_config(raw):
     return config
 
 
+def _redacted_zone_config(config):
+    """Zone config safe to send to clients: speaker passwords become a name list."""
+    if not config.get("speaker_passwords"):
+        return config
+    redacted = dict(config)
+    redacted["speaker_passwords"] = sorted(config["speaker_passwords"])
+    return redacted
+
+
 def _settings_to_mix(settings):
     reduction_pct = _clamp_int(
         settings.get("reduction_pct"),
@@ -170,15 +374,25 @@ class Zone:
         # Runtime state (populated when running)
         self.allocated_subdevice = None
         self.shairport_pid = None
+        self.shairport_proc = None
         self.mixer_pid = None
+        self.mixer_proc = None
+        self.metadata_reader = None
         self.owntone_pid = None
+        self.owntone_proc = None
         self.shairport_ip = None
         self.owntone_ip = None
         self.shairport_port = None
         self.owntone_port = None
         self.tts_webrtc_socket = None
+        self.muted = False
         self.owntone_api = None  # OwnToneAPI instance
         self.excluded_airplay_names = []
+        self.group_speaker_names = []  # Resolved from speaker groups at start
+        self.speaker_types = {}  # Speaker name -> OwnTone output type, set at start
+        # Lifetime counters for /metrics; they survive zone restarts.
+        self.mixer_restarts = 0
+        self.process_failures = 0
         self._grp_dir = None
         self._stop_event = threading.Event()
 
@@ -211,11 +425,21 @@ class Zone:
         if self.on_status_change:
             self.on_status_change(self)
 
+    def now_playing(self):
+        """Latest Shairport metadata for the zone, or None when it is not running."""
+        reader = self.metadata_reader
+        return reader.now_playing() if reader else None
+
+    def cover_art(self):
+        """Current track's cover art as (bytes, mime), or (None, None)."""
+        reader = self.metadata_reader
+        return reader.cover_art() if reader else (None, None)
+
     def to_dict(self):
         """Serialize zone state for API response."""
         return {
             "zone_id": self.zone_id,
-            "config": self.config,
+            "config": _redacted_zone_config(self.config),
             "status": self.status,
             "error_message": self.error_message,
             "shairport_ip": self.shairport_ip,
@@ -223,6 +447,8 @@ class Zone:
             "shairport_port": self.shairport_port,
             "owntone_port": self.owntone_port,
             "tts_webrtc_socket": self.tts_webrtc_socket,
+            "muted": self.muted,
+            "now_playing": self.now_playing(),
             "allocated_subdevice": self.allocated_subdevice,
             "latency_offset": self.config.get("latency_offset", DEFAULT_LATENCY_OFFSET),
             "lionos_room_id": self.lionos_room_id,
@@ -286,8 +512,41 @@ class ZoneManager:
         """Remove stale Shiri namespaces/processes left by an unclean daemon exit."""
         cleanup_stale_runtime()
 
-    def get_network_interfaces(self):
-        """Return list of interface names excluding loopback."""
+    def get_network_interfaces(self, include_virtual=False):
+        """Return interface names excluding loopback.
+
+        Unless include_virtual is set, only NICs backed by a device are listed.
+        Names in the interface_allowlist setting, the default interface and
+        interfaces already used by zones are always kept.
+        """
+        interfaces = self._list_links()
+        if include_virtual:
+            return interfaces
+        settings = self.config_store.get_settings()
+        keep = set(settings.get("interface_allowlist") or [])
+        keep.add(settings.get("default_interface") or "")
+        keep.update(zone.interface for zone in list(self.zones.values()))
+        physical = [iface for iface in interfaces if iface in keep or _is_physical_interface(iface)]
+        if not physical:
+            # No /sys device links (some containers); better a noisy list than none.
+            return interfaces
+        return physical
+
+    def get_network_interface_details(self, interfaces=None):
+        """Return [{name, up, wireless, mac}] for the given (or listed) interfaces."""
+        details = []
+        for name in interfaces if interfaces is not None else self.get_network_interfaces():
+            sys_dir = f"/sys/class/net/{name}"
+            details.append({
+                "name": name,
+                "up": _read_sys_value(os.path.join(sys_dir, "operstate")) == "up",
+                "wireless": (os.path.isdir(os.path.join(sys_dir, "wireless"))
+                             or os.path.exists(os.path.join(sys_dir, "phy80211"))),
+                "mac": _read_sys_value(os.path.join(sys_dir, "address")),
+            })
+        return details
+
+    def _list_links(self):
         try:
             result = _run(["ip", "-o", "link", "show"])
         except OSError as exc:
@@ -307,10 +566,13 @@ class ZoneManager:
 
     def get_system_status(self):
         """Return system-level health info."""
+        interfaces = self.get_network_interfaces()
         return {
             "nqptp_mode": "per-zone-netns",
             "alsa_ready": self._alsa_ready,
-            "interfaces": self.get_network_interfaces(),
+            "missing_binaries": missing_binaries(),
+            "interfaces": interfaces,
+            "interface_details": self.get_network_interface_details(interfaces),
             "zone_count": len(self.zones),
             "running_zones": sum(1 for z in self.zones.values()
                                  if z.status == Zone.STATUS_RUNNING),
@@ -320,8 +582,89 @@ class ZoneManager:
     # Zone CRUD
     # -------------------------------------------------------------------------
 
+    def _zone_name_taken(self, name, exclude_zone_id=None):
+        key = zone_name_key(name)
+        return any(
+            zone_id != exclude_zone_id and zone_name_key(zone.config.get("name")) == key
+            for zone_id, zone in self.zones.items()
+        )
+
+    def speaker_groups(self):
+        return self.config_store.get_settings().get("speaker_groups") or []
+
+    def _validated_group_refs(self, refs):
+        if not refs:
+            return []
+        if not isinstance(refs, list):
+            raise SpeakerGroupError("speaker_groups must be a list of group names")
+        known = {group["name"] for group in self.speaker_groups()}
+        refs = list(dict.fromkeys(str(ref).strip() for ref in refs if str(ref).strip()))
+        unknown = [ref for ref in refs if ref not in known]
+        if unknown:
+            raise SpeakerGroupError(f"Unknown speaker group(s): {', '.join(unknown)}")
+        return refs
+
+    def _group_speaker_names(self, zone):
+        """Speaker names from the zone's groups, resolved against the current settings."""
+        groups = {group["name"]: group["speakers"] for group in self.speaker_groups()}
+        names = []
+        for ref in zone.config.get("speaker_groups") or []:
+            if ref not in groups:
+                log.warning("Zone %s uses speaker group '%s', which no longer exists", zone.display_name, ref)
+                continue
+            names.extend(name for name in groups[ref] if name not in names)
+        return names
+
+    def _saved_speaker_types(self, zone):
+        """Output types of speakers saved in any zone, by name.
+
+        Group speakers are stored by name only; another zone's saved
+        selection is often the only record of what kind of output they are.
+        The zone's own selection wins.
+        """
+        types = {}
+        for other in [item for item in self.list_zones() if item is not zone] + [zone]:
+            for speaker in other.config.get("speaker_names") or []:
+                if isinstance(speaker, dict) and speaker.get("name") and speaker.get("type"):
+                    types[str(speaker["name"])] = str(speaker["type"])
+        return types
+
+    def _static_ip_owner(self, static_ip, exclude_zone_id=None):
+        """Return the other zone whose static address matches static_ip, if any."""
+        if not static_ip:
+            return None
+        ip = static_ip.split("/", 1)[0]
+        for zone_id, zone in self.zones.items():
+            other = zone.config.get("static_ip")
+            if zone_id != exclude_zone_id and other and other.split("/", 1)[0] == ip:
+                return zone
+        return None
+
+    def _assign_loopback_subdevice(self, zone):
+        """Give a zone the lowest subdevice no other zone prefers (caller holds the lock).
+
+        The subdevice fixes the zone's Shairport, OwnTone and UDP ports, so
+        keeping it per zone keeps those ports stable across restarts.
+        """
+        if zone.config.get("loopback_subdevice") is not None:
+            return
+        taken = {
+            other.config.get("loopback_subdevice")
+            for other in self.zones.values()
+            if other is not zone
+        }
+        for subdev in range(LOOPBACK_SUBDEVICE_COUNT):
+            if subdev not in taken:
+                zone.config["loopback_subdevice"] = subdev
+                return
+
     def create_zone(self, name, interface, auto_start=False, latency_offset=None):
-        """Create a new zone (does not start it)."""
+        """Create a new zone (does not start it).
+
+        Raises InvalidZoneNameError for a name AirPlay cannot advertise and
+        DuplicateZoneNameError if another zone already uses the name.
+        """
+        name = normalize_airplay_name(name)
         zone_id = f"zone_{uuid.uuid4().hex[:8]}"
         config = {
             "name": name,
@@ -334,29 +677,40 @@ class ZoneManager:
             config["latency_offset"] = normalize_latency_offset(latency_offset)
         zone = Zone(zone_id, config, on_status_change=self._emit_zone_status)
         with self._lock:
+            if self._zone_name_taken(name):
+                raise DuplicateZoneNameError(name)
+            self._assign_loopback_subdevice(zone)
             self.zones[zone_id] = zone
         self.config_store.save_zone(zone_id, config)
         self._emit_zone_status(zone)
         log.info("Created zone %s (%s)", zone_id, name)
         return zone
 
-    def delete_zone(self, zone_id):
-        """Stop and remove a zone. Waits for stop to complete before deleting."""
+    def delete_zone(self, zone_id, force=False):
+        """Stop and remove a zone. Waits for stop to complete before deleting.
+
+        Returns (deleted, error). A zone whose processes did not stop is kept
+        unless force=True, so the UI does not lose track of live processes.
+        """
         with self._lock:
             zone = self.zones.get(zone_id)
             if not zone:
-                return False
+                return False, "Zone not found"
         
         # If zone is running, stop it and wait for completion
         if zone.status in (Zone.STATUS_RUNNING, Zone.STATUS_STARTING, Zone.STATUS_STOPPING):
             self.stop_zone(zone_id)
-            # Wait for zone to actually stop (up to 30 seconds)
+            # Wait for the stop worker to finish (up to 30 seconds)
             for _ in range(60):
-                if zone.status == Zone.STATUS_STOPPED:
+                if zone.status not in (Zone.STATUS_RUNNING, Zone.STATUS_STARTING, Zone.STATUS_STOPPING):
                     break
                 time.sleep(0.5)
-            else:
-                log.warning("Zone %s did not stop in time, deleting anyway", zone_id)
+            if zone.status != Zone.STATUS_STOPPED:
+                detail = zone.error_message or f"still {zone.status}"
+                if not force:
+                    log.warning("Zone %s did not stop cleanly, not deleting: %s", zone_id, detail)
+                    return False, f"Zone did not stop cleanly ({detail})"
+                log.warning("Zone %s did not stop cleanly, force deleting: %s", zone_id, detail)
         
         # Prevent the background stop thread from emitting 'stopped' and reviving the zone on the UI
         zone.on_status_change = None 
@@ -369,15 +723,36 @@ class ZoneManager:
         if self.socketio:
             self.socketio.emit("zone_deleted", {"zone_id": zone_id})
         log.info("Deleted zone %s", zone_id)
-        return True
+        return True, None
 
     def update_zone_config(self, zone_id, updates, restart_if_running=False):
         """Update zone config (name, interface, etc.). 
-        If restart_if_running=True and zone is running, it will be restarted."""
+        If restart_if_running=True and zone is running, it will be restarted.
+        Raises InvalidZoneNameError or DuplicateZoneNameError for a bad new name,
+        StaticAddressError for an invalid or duplicate static_ip/static_gateway,
+        SpeakerGroupError for a speaker_groups entry that is not defined, and
+        ShairportArgsError for unsafe shairport_args."""
         with self._lock:
             zone = self.zones.get(zone_id)
             if not zone:
                 return None, False
+            if "name" in updates:
+                updates = dict(updates, name=normalize_airplay_name(updates.get("name")))
+                if self._zone_name_taken(updates["name"], exclude_zone_id=zone_id):
+                    raise DuplicateZoneNameError(updates["name"])
+            if "speaker_groups" in updates:
+                group_refs = self._validated_group_refs(updates.get("speaker_groups"))
+            if "shairport_args" in updates:
+                shairport_args = normalize_shairport_args(updates.get("shairport_args"))
+            static_changed = "static_ip" in updates or "static_gateway" in updates
+            if static_changed:
+                static_ip, static_gateway = _normalize_static_address(
+                    updates.get("static_ip", zone.config.get("static_ip")),
+                    updates.get("static_gateway", zone.config.get("static_gateway")),
+                )
+                owner = self._static_ip_owner(static_ip, exclude_zone_id=zone_id)
+                if owner:
+                    raise StaticAddressError(f"{static_ip.split('/')[0]} is already assigned to {owner.display_name}")
             
             was_running = zone.status == Zone.STATUS_RUNNING
             
@@ -387,8 +762,28 @@ class ZoneManager:
             sanitized = _sanitize_zone_config(updates)
             for binding_key in ("lionos_room_id", "lionos_room_name", "default_lionos_room"):
                 sanitized.pop(binding_key, None)
+            # Passwords only change through set_speaker_password; the
+            # subdevice is assigned by the manager.
+            sanitized.pop("speaker_passwords", None)
+            sanitized.pop("loopback_subdevice", None)
             if "tts_policy" in sanitized:
                 sanitized["tts_policy"] = _normalize_tts_policy(sanitized.get("tts_policy"))
+            if "speaker_groups" in sanitized:
+                sanitized["speaker_groups"] = group_refs
+            if "shairport_args" in sanitized:
+                sanitized.pop("shairport_args")
+                zone.config.pop("shairport_args", None)
+                if shairport_args:
+                    sanitized["shairport_args"] = shairport_args
+            if static_changed:
+                sanitized.pop("static_ip", None)
+                sanitized.pop("static_gateway", None)
+                zone.config.pop("static_ip", None)
+                zone.config.pop("static_gateway", None)
+                if static_ip:
+                    sanitized["static_ip"] = static_ip
+                if static_gateway:
+                    sanitized["static_gateway"] = static_gateway
             zone.config.update(sanitized)
             zone.config = _sanitize_zone_config(zone.config)
         
@@ -420,6 +815,28 @@ class ZoneManager:
         with self._lock:
             return list(self.zones.values())
 
+    def reorder_zones(self, zone_ids):
+        """Set the display order of zones. Returns (zone_ids, error).
+
+        Order is cosmetic: ports and loopback subdevices are allocated per
+        zone, so running zones are unaffected.
+        """
+        if not isinstance(zone_ids, list):
+            return None, "zone_ids must be a list"
+        zone_ids = [str(zone_id) for zone_id in zone_ids]
+        if len(set(zone_ids)) != len(zone_ids):
+            return None, "zone_ids contains duplicates"
+        with self._lock:
+            unknown = [zone_id for zone_id in zone_ids if zone_id not in self.zones]
+            if unknown:
+                return None, f"Unknown zone(s): {', '.join(unknown)}"
+            ordered = {zone_id: self.zones[zone_id] for zone_id in zone_ids}
+            ordered.update((zone_id, zone) for zone_id, zone in self.zones.items() if zone_id not in ordered)
+            self.zones = ordered
+            order = list(ordered)
+        self.config_store.reorder_zones(order)
+        return order, None
+
     def load_saved_zones(self):
         """Load zones from persistent config (called on startup)."""
         saved = self.config_store.list_zones()
@@ -428,9 +845,29 @@ class ZoneManager:
             if sanitized != config:
                 self.config_store.save_zone(zone_id, sanitized)
                 config = sanitized
+            try:
+                normalize_airplay_name(config.get("name"))
+            except InvalidZoneNameError as e:
+                log.warning("Zone %s: %s; rename it before starting", zone_id, e)
+            if "shairport_args" in config:
+                try:
+                    shairport_args = normalize_shairport_args(config.get("shairport_args"))
+                except ShairportArgsError as e:
+                    log.warning("Zone %s: ignoring shairport_args: %s", zone_id, e)
+                    shairport_args = []
+                if shairport_args != config.get("shairport_args"):
+                    config = dict(config)
+                    config.pop("shairport_args")
+                    if shairport_args:
+                        config["shairport_args"] = shairport_args
+                    self.config_store.save_zone(zone_id, config)
+            preferred = config.get("loopback_subdevice")
             zone = Zone(zone_id, config, on_status_change=self._emit_zone_status)
             with self._lock:
+                self._assign_loopback_subdevice(zone)
                 self.zones[zone_id] = zone
+            if zone.config.get("loopback_subdevice") != preferred:
+                self.config_store.save_zone(zone_id, zone.config)
             log.info("Loaded saved zone: %s (%s)", zone_id, config.get("name"))
 
     def cleanup_orphaned_group_dirs(self):
@@ -644,7 +1081,7 @@ class ZoneManager:
         return [
             output
             for output in outputs
-            if str(output.get("type") or "") in {"AirPlay 2", "ALSA"}
+            if str(output.get("type") or "") in SPEAKER_OUTPUT_TYPES
             and not self._is_shiri_airplay_output(output)
         ]
 
@@ -687,6 +1124,7 @@ class ZoneManager:
                 selected_speakers.append({
                     "id": out.get("id"),
                     "name": out.get("name", "Unknown"),
+                    "type": out.get("type"),
                 })
         
         # Save speaker selection with names for restoration
@@ -723,6 +1161,7 @@ class ZoneManager:
                     selected_speakers.append({
                         "id": out.get("id"),
                         "name": out.get("name", "Unknown"),
+                        "type": out.get("type"),
                     })
             zone.config["speakers"] = selected_ids
             zone.config["speaker_names"] = selected_speakers
@@ -732,6 +1171,90 @@ class ZoneManager:
 
         return True, None
 
+    def reconnect_speaker(self, zone_id, speaker_id):
+        """Drop and re-open one speaker's AirPlay session, leaving the zone's
+        other outputs playing. Returns (ok, error) once the session is dropped;
+        the outcome of the re-enable is emitted as speaker_reconnected."""
+        zone = self.get_zone(zone_id)
+        if not zone or not zone.owntone_api:
+            return False, "Zone not running or not found"
+
+        outputs = self._external_speaker_outputs(zone.owntone_api.get_outputs())
+        output = next((out for out in outputs if str(out.get("id")) == str(speaker_id)), None)
+        if not output:
+            return False, "Only real speaker outputs can be reconnected"
+        if not output.get("selected"):
+            return False, "Speaker is not enabled for this zone"
+
+        log.info("Reconnecting speaker %s (%s) in zone %s",
+                 output.get("name"), speaker_id, zone.display_name)
+        zone.owntone_api.disable_output(speaker_id)
+        # Give OwnTone time to tear the session down before opening a new one.
+        timer = threading.Timer(
+            SPEAKER_RECONNECT_DELAY_SECONDS, self._finish_speaker_reconnect,
+            args=(zone, speaker_id, output.get("name")))
+        timer.daemon = True
+        timer.start()
+        return True, None
+
+    def _finish_speaker_reconnect(self, zone, speaker_id, speaker_name):
+        error = None
+        if not zone.owntone_api:
+            error = "Zone stopped before the speaker was re-enabled"
+        else:
+            zone.owntone_api.enable_output(speaker_id)
+            # OwnTone answers the PUT with 204 and no body, so check the output
+            # list rather than the response.
+            outputs = zone.owntone_api.get_outputs()
+            output = next((out for out in outputs if str(out.get("id")) == str(speaker_id)), None)
+            if not output or not output.get("selected"):
+                error = "OwnTone did not re-enable the speaker"
+        if error:
+            log.warning("Reconnect of speaker %s in zone %s failed: %s", speaker_name, zone.display_name, error)
+        if self.socketio:
+            self.socketio.emit("speaker_reconnected", {
+                "zone_id": zone.zone_id,
+                "speaker_id": str(speaker_id),
+                "speaker_name": speaker_name,
+                "ok": error is None,
+                "error": error,
+            })
+
+    def set_speaker_password(self, zone_id, speaker_id, password):
+        """Store (or clear, with an empty password) the AirPlay password for a
+        speaker. OwnTone reads it from its config, so a running zone needs a
+        restart to use it. Returns (result_dict, error)."""
+        zone = self.get_zone(zone_id)
+        if not zone:
+            return None, "Zone not found"
+        speaker = next(
+            (item for item in self._known_speakers(zone) if str(item.get("id")) == str(speaker_id)),
+            None,
+        )
+        if not speaker or not speaker.get("name"):
+            return None, "Speaker not found"
+        password = str(password or "")
+        if any(ch in password for ch in "\r\n"):
+            return None, "Password cannot contain line breaks"
+
+        passwords = dict(zone.config.get("speaker_passwords") or {})
+        if password:
+            passwords[speaker["name"]] = password
+        else:
+            passwords.pop(speaker["name"], None)
+        if passwords:
+            zone.config["speaker_passwords"] = passwords
+        else:
+            zone.config.pop("speaker_passwords", None)
+        self.config_store.save_zone(zone_id, zone.config)
+        log.info("%s AirPlay password for %s in zone %s",
+                 "Saved" if password else "Cleared", speaker["name"], zone.display_name)
+        return {
+            "speaker": speaker["name"],
+            "has_password": bool(password),
+            "restart_required": zone.status == Zone.STATUS_RUNNING,
+        }, None
+
     # -------------------------------------------------------------------------
     # Volume management
     # -------------------------------------------------------------------------
@@ -774,6 +1297,25 @@ class ZoneManager:
         zone.config["master_volume"] = volume
         self.config_store.save_zone(zone.zone_id, zone.config)
 
+    def set_muted(self, zone_id, muted):
+        """Silence or restore a running zone's music without stopping it.
+        Returns (result_dict, error)."""
+        zone = self.get_zone(zone_id)
+        if not zone:
+            return None, "Zone not found"
+        if zone.status != Zone.STATUS_RUNNING or not zone.tts_webrtc_socket:
+            return None, "Zone is not running"
+        try:
+            response = _send_mixer_request(zone.tts_webrtc_socket, {"action": "mute", "muted": bool(muted)})
+        except (OSError, RuntimeError, ValueError) as e:
+            return None, f"Mixer did not accept mute: {e}"
+        if not response.get("ok", False):
+            return None, str(response.get("error") or "Mixer rejected mute request")
+        zone.muted = bool(response.get("muted"))
+        self._emit_zone_status(zone)
+        log.info("%s zone %s", "Muted" if zone.muted else "Unmuted", zone_id)
+        return {"ok": True, "zone_id": zone_id, "muted": zone.muted}, None
+
     # -------------------------------------------------------------------------
     # Latency management
     # -------------------------------------------------------------------------
@@ -827,6 +1369,8 @@ class ZoneManager:
             return False
 
         zone.excluded_airplay_names = sorted(self._shiri_airplay_output_names())
+        zone.group_speaker_names = self._group_speaker_names(zone)
+        zone.speaker_types = self._saved_speaker_types(zone)
         zone._set_status(Zone.STATUS_STARTING)
         t = threading.Thread(
             target=start_zone_thread, args=(zone, cleanup_zone),
@@ -834,6 +1378,74 @@ class ZoneManager:
         t.start()
         return True
 
+    def start_all(self, zone_ids=None, stagger=None, wait=False, timeout=START_ALL_ZONE_TIMEOUT_SECONDS):
+        """Start several zones one after another with a delay between each.
+
+        Concurrent starts race on macvlan creation and DHCP on the shared NIC,
+        so each zone must reach RUNNING or ERROR (or time out) before the
+        stagger delay and the next start. The sequence runs in a background
+        worker, or inline when wait=True. Returns a report of queued and
+        skipped zones; with wait=True it also has per-zone results
+        (ok/error/timeout), which are otherwise emitted as start_all_finished.
+        """
+        if stagger is None:
+            stagger = self.config_store.get_settings().get(
+                "start_stagger_seconds", DEFAULT_START_STAGGER_SECONDS)
+        stagger = _clamp_float(stagger, 0.0, MAX_START_STAGGER_SECONDS,
+                               DEFAULT_START_STAGGER_SECONDS)
+        if zone_ids is None:
+            zones = self.list_zones()
+        else:
+            zones = [self.get_zone(zone_id) or zone_id for zone_id in zone_ids]
+
+        report = {"queued": [], "skipped": [], "stagger_seconds": stagger}
+        eligible = []
+        for zone in zones:
+            if not isinstance(zone, Zone):
+                report["skipped"].append({"zone_id": zone, "reason": "Zone not found"})
+            elif zone.status != Zone.STATUS_STOPPED:
+                report["skipped"].append({"zone_id": zone.zone_id, "reason": f"Zone is {zone.status}"})
+            else:
+                eligible.append(zone)
+                report["queued"].append(zone.zone_id)
+
+        def run():
+            results = []
+            for index, zone in enumerate(eligible):
+                if index and stagger:
+                    time.sleep(stagger)
+                if self.start_zone(zone.zone_id):
+                    outcome = self._wait_for_start(zone, timeout)
+                else:
+                    outcome = "error"
+                result = {"zone_id": zone.zone_id, "name": zone.display_name, "result": outcome}
+                if outcome != "ok":
+                    result["error"] = zone.error_message or f"still {zone.status}"
+                    log.warning("Start all: zone %s did not start (%s: %s)",
+                                zone.zone_id, outcome, result["error"])
+                results.append(result)
+            if self.socketio:
+                self.socketio.emit("start_all_finished", {"results": results, "skipped": report["skipped"]})
+            return results
+
+        log.info("Starting %d zones with %.1fs stagger", len(eligible), stagger)
+        if wait:
+            report["results"] = run()
+        else:
+            threading.Thread(target=run, daemon=True, name="start-all").start()
+        return report
+
+    def _wait_for_start(self, zone, timeout):
+        """Wait for a starting zone to settle. Returns "ok", "error" or "timeout"."""
+        deadline = time.monotonic() + timeout
+        while time.monotonic() < deadline:
+            if zone.status == Zone.STATUS_RUNNING:
+                return "ok"
+            if zone.status in (Zone.STATUS_ERROR, Zone.STATUS_STOPPED):
+                return "error"
+            time.sleep(0.5)
+        return "timeout"
+
     def stop_zone(self, zone_id):
         """Stop a running zone."""
         zone = self.get_zone(zone_id)
@@ -850,6 +1462,58 @@ class ZoneManager:
         t.start()
         return True
 
+    def stop_all(self, zone_ids=None, wait=True, timeout=30):
+        """Stop several zones at once and report how each one ended.
+
+        Stops run in parallel; sender namespace teardown is serialized inside
+        zone_lifecycle. With wait=True, returns once every zone has left
+        STOPPING (or timeout expires) with stopped/failed/skipped lists.
+        Otherwise returns the stopping/skipped lists at once and emits the
+        final report as stop_all_finished.
+        """
+        if zone_ids is None:
+            zones = self.list_zones()
+        else:
+            zones = [self.get_zone(zone_id) or zone_id for zone_id in zone_ids]
+
+        report = {"stopped": [], "failed": [], "skipped": []}
+        stopping = []
+        for zone in zones:
+            if not isinstance(zone, Zone):
+                report["skipped"].append({"zone_id": zone, "reason": "Zone not found"})
+            elif self.stop_zone(zone.zone_id):
+                stopping.append(zone)
+            else:
+                report["skipped"].append({"zone_id": zone.zone_id, "reason": f"Zone is {zone.status}"})
+
+        def collect():
+            deadline = time.monotonic() + timeout
+            while time.monotonic() < deadline and any(
+                    zone.status == Zone.STATUS_STOPPING for zone in stopping):
+                time.sleep(0.5)
+            for zone in stopping:
+                if zone.status == Zone.STATUS_STOPPED:
+                    report["stopped"].append(zone.zone_id)
+                else:
+                    report["failed"].append({
+                        "zone_id": zone.zone_id,
+                        "name": zone.display_name,
+                        "error": zone.error_message or f"still {zone.status}",
+                    })
+            return report
+
+        log.info("Stopping %d zones", len(stopping))
+        if wait:
+            return collect()
+
+        def run():
+            result = collect()
+            if self.socketio:
+                self.socketio.emit("stop_all_finished", result)
+
+        threading.Thread(target=run, daemon=True, name="stop-all").start()
+        return {"stopping": [zone.zone_id for zone in stopping], "skipped": report["skipped"]}
+
     # -------------------------------------------------------------------------
     # Diagnostic monitoring for AirPlay disconnect debugging
     # -------------------------------------------------------------------------
@@ -858,6 +1522,8 @@ class ZoneManager:
         """Start background thread that polls OwnTone player state for all running zones."""
         self._diag_stop = threading.Event()
         self._diag_last_state = {}  # zone_id -> last known state dict
+        self._mixer_restarts = {}  # zone_id -> {"attempts", "next_at", "started_at", "proc"}
+        self._failure_restarts = {}  # zone_id -> [monotonic restart times]
         t = threading.Thread(target=self._diagnostic_monitor_loop, daemon=True,
                              name="diag-monitor")
         t.start()
@@ -870,7 +1536,13 @@ class ZoneManager:
 
         while not self._diag_stop.is_set():
             for zone_id, zone in list(self.zones.items()):
-                if zone.status != Zone.STATUS_RUNNING or not zone.owntone_api:
+                if zone.status != Zone.STATUS_RUNNING:
+                    self._mixer_restarts.pop(zone_id, None)
+                    continue
+                if self._check_zone_processes(zone):
+                    continue
+                self._check_mixer(zone)
+                if not zone.owntone_api:
                     continue
                 try:
                     player = zone.owntone_api.get_player_status()
@@ -932,6 +1604,108 @@ class ZoneManager:
 
             self._diag_stop.wait(2)
 
+    def _check_zone_processes(self, zone):
+        """Handle Shairport or OwnTone exiting under a running zone.
+
+        The zone is torn down and either restarted (restart_on_failure) or left
+        in error with the reason. Returns True if a failure was handled.
+        """
+        dead = []
+        for label, proc in (("shairport-sync", zone.shairport_proc), ("owntone", zone.owntone_proc)):
+            if proc is not None and proc.poll() is not None:
+                dead.append(f"{label} exited with code {proc.returncode}")
+        if not dead:
+            return False
+
+        reason = "; ".join(dead)
+        zone.process_failures += 1
+        restart = bool(zone.config.get("restart_on_failure", False))
+        if restart:
+            now = time.monotonic()
+            recent = [t for t in self._failure_restarts.get(zone.zone_id, [])
+                      if now - t < FAILURE_RESTART_WINDOW_SECONDS]
+            if len(recent) >= FAILURE_RESTART_LIMIT:
+                restart = False
+                reason += (f"; not restarting after {FAILURE_RESTART_LIMIT} restarts in "
+                           f"{FAILURE_RESTART_WINDOW_SECONDS / 60:.0f} minutes")
+            else:
+                recent.append(now)
+            self._failure_restarts[zone.zone_id] = recent
+
+        log.error("Zone %s failed: %s%s", zone.display_name, reason, " (restarting)" if restart else "")
+        zone._stop_event.set()
+        zone._set_status(Zone.STATUS_STOPPING, reason)
+        threading.Thread(target=self._recover_failed_zone, args=(zone, reason, restart),
+                         daemon=True, name=f"recover-{zone.zone_id}").start()
+        return True
+
+    def _recover_failed_zone(self, zone, reason, restart):
+        try:
+            cleanup_zone(zone)
+        except Exception as e:
+            log.error("Cleanup after failure of %s was incomplete: %s", zone.zone_id, e)
+            zone._set_status(Zone.STATUS_ERROR, f"{reason}; cleanup error: {e}")
+            return
+        zone._stop_event.clear()
+        if restart:
+            zone._set_status(Zone.STATUS_STOPPED)
+            self.start_zone(zone.zone_id)
+        else:
+            zone._set_status(Zone.STATUS_ERROR, reason)
+
+    def _check_mixer(self, zone):
+        """Restart a running zone's mixer if it exited, with exponential backoff."""
+        proc = zone.mixer_proc
+        if proc is None:
+            return
+        now = time.monotonic()
+        state = self._mixer_restarts.get(zone.zone_id)
+        if state is None or state["proc"] is not proc:
+            # First sighting of this mixer process (zone start or our own restart).
+            attempts = state["attempts"] if state else 0
+            state = {"attempts": attempts, "next_at": 0.0, "started_at": now, "proc": proc}
+            self._mixer_restarts[zone.zone_id] = state
+        returncode = proc.poll()
+        if returncode is None:
+            if state["attempts"] and now - state["started_at"] >= MIXER_STABLE_SECONDS:
+                state["attempts"] = 0
+            return
+        if now < state["next_at"]:
+            return
+        if zone.status != Zone.STATUS_RUNNING:
+            return
+
+        delay = min(MIXER_RESTART_BACKOFF_MAX_SECONDS,
+                    MIXER_RESTART_BACKOFF_SECONDS * (2 ** state["attempts"]))
+        state["attempts"] += 1
+        state["next_at"] = now + delay
+        zone.mixer_restarts += 1
+        log.error("Mixer for %s exited with code %s; restarting (attempt %d, next retry in %.0fs)",
+                  zone.display_name, returncode, state["attempts"], delay)
+        try:
+            restart_mixer(zone)
+        except Exception as e:
+            log.error("Could not restart mixer for %s: %s", zone.display_name, e)
+            return
+        if zone.muted:
+            threading.Thread(target=self._reapply_mute, args=(zone, zone.mixer_proc),
+                             daemon=True, name=f"remute-{zone.zone_id}").start()
+
+    def _reapply_mute(self, zone, proc, timeout=10):
+        """Restore mute on a freshly restarted mixer once its socket is up."""
+        deadline = time.monotonic() + timeout
+        while time.monotonic() < deadline:
+            if zone.mixer_proc is not proc or zone.status != Zone.STATUS_RUNNING or not zone.muted:
+                return
+            try:
+                if _send_mixer_request(zone.tts_webrtc_socket, {"action": "mute", "muted": True}).get("ok"):
+                    log.info("Re-applied mute for %s after mixer restart", zone.display_name)
+                    return
+            except (OSError, RuntimeError, ValueError):
+                pass
+            time.sleep(0.5)
+        log.warning("Could not re-apply mute for %s after mixer restart", zone.display_name)
+
     def stop_diagnostic_monitor(self):
         if hasattr(self, '_diag_stop'):
             self._diag_stop.set()
@@ -956,6 +1730,9 @@ class ZoneManager:
         for zone_id in list(self.zones.keys()):
             zone = self.zones[zone_id]
             if zone.status in (Zone.STATUS_RUNNING, Zone.STATUS_STARTING):
-                cleanup_zone(zone)
+                try:
+                    cleanup_zone(zone)
+                except Exception as e:
+                    log.error("Zone %s did not shut down cleanly: %s", zone_id, e)
                 zone._set_status(Zone.STATUS_STOPPED)
         log.info("All zones stopped")
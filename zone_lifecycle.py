@@ -9,18 +9,24 @@ API concerns while lifecycle implementation details stay isolated.
 
 import logging
 import hashlib
+import ipaddress
 import os
 import signal
 import shlex
 import shutil
+import stat
 import subprocess
 import threading
 import time
 
 from owntone_api import OwnToneAPI
+from shairport_metadata import MetadataReader
 from config import (
+    AIRPLAY_OUTPUT_TYPE,
     BASE_DIR,
     OWNTONE_PORT_BASE,
+    OWNTONE_WEBSOCKET_PORT_BASE,
+    OWNTONE_MPD_PORT_BASE,
     OWNTONE_SENDER_NS,
     OWNTONE_SENDER_IFACE,
     OWNTONE_API_HOST_IFACE,
@@ -47,6 +53,27 @@ PREFERRED_BINARIES = {
     "owntone": "/usr/local/sbin/owntone",
     "shairport-sync": "/usr/local/bin/shairport-sync",
 }
+# Everything a zone start execs. Checked up front so a missing package fails
+# the start before any namespace, macvlan or loopback subdevice is claimed.
+REQUIRED_BINARIES = (
+    "shairport-sync",
+    "owntone",
+    "nqptp",
+    "airptpd",
+    "avahi-daemon",
+    "dbus-daemon",
+    "dhclient",
+    "ip",
+    "unshare",
+    "stdbuf",
+    "chrt",
+    "curl",
+    # Static addresses are probed for LAN conflicts and gateway reachability.
+    "ping",
+)
+# OwnTone output types Shiri lets a zone play to. Chromecast outputs only
+# appear when OwnTone was built with Chromecast support.
+SPEAKER_OUTPUT_TYPES = frozenset({AIRPLAY_OUTPUT_TYPE, "ALSA", "Chromecast"})
 _SENDER_LOCK = threading.RLock()
 
 
@@ -76,57 +103,78 @@ def _run(cmd, check=False, **kwargs):
 
 
 def _kill_pid(pid, label="process"):
-    """Gracefully kill a PID (TERM then KILL)."""
+    """Gracefully kill a PID (TERM then KILL). Returns False if it survived."""
     if pid is None:
-        return
+        return True
     if _pid_is_zombie(pid):
         _reap_pid(pid)
-        return
+        return True
     try:
         os.kill(pid, signal.SIGTERM)
         log.info("Sent SIGTERM to %s (pid %d)", label, pid)
     except ProcessLookupError:
-        return
+        return True
     time.sleep(1)
     if _pid_is_zombie(pid):
         _reap_pid(pid)
-        return
+        return True
     try:
         os.kill(pid, signal.SIGKILL)
         log.info("Sent SIGKILL to %s (pid %d)", label, pid)
     except ProcessLookupError:
-        pass
+        return True
+    return _wait_pid_gone(pid, label)
 
 
 def _terminate_pid(pid, label="process", timeout=5):
-    """Gracefully terminate a PID, allowing a longer cleanup window."""
+    """Gracefully terminate a PID, allowing a longer cleanup window.
+
+    Returns False if the process is still alive after SIGKILL.
+    """
     if pid is None:
-        return
+        return True
     if _pid_is_zombie(pid):
         _reap_pid(pid)
-        return
+        return True
     try:
         os.kill(pid, signal.SIGTERM)
         log.info("Sent SIGTERM to %s (pid %d)", label, pid)
     except ProcessLookupError:
-        return
+        return True
 
     deadline = time.time() + timeout
     while time.time() < deadline:
         if _pid_is_zombie(pid):
             _reap_pid(pid)
-            return
+            return True
         try:
             os.kill(pid, 0)
         except ProcessLookupError:
-            return
+            return True
         time.sleep(0.2)
 
     try:
         os.kill(pid, signal.SIGKILL)
         log.info("Sent SIGKILL to %s (pid %d)", label, pid)
     except ProcessLookupError:
-        pass
+        return True
+    return _wait_pid_gone(pid, label)
+
+
+def _wait_pid_gone(pid, label, timeout=2):
+    """Confirm a SIGKILLed PID is gone; D-state processes can outlive it."""
+    deadline = time.time() + timeout
+    while time.time() < deadline:
+        if _pid_is_zombie(pid):
+            _reap_pid(pid)
+            return True
+        try:
+            os.kill(pid, 0)
+        except ProcessLookupError:
+            return True
+        time.sleep(0.1)
+    log.error("%s (pid %d) is still alive after SIGKILL", label, pid)
+    return False
 
 
 def _read_text(path):
@@ -340,6 +388,41 @@ def _netns_exec(ns, args, **kwargs):
     return _run(["ip", "netns", "exec", ns] + args, **kwargs)
 
 
+def _netns_listening_tcp_ports(ns):
+    """Return TCP ports in LISTEN state inside a namespace, from /proc/net/tcp{,6}."""
+    ports = set()
+    for table in ("/proc/net/tcp", "/proc/net/tcp6"):
+        result = _netns_exec(ns, ["cat", table])
+        if result.returncode != 0:
+            continue
+        for line in (result.stdout or "").splitlines()[1:]:
+            fields = line.split()
+            # fields: sl local_address rem_address st ...; st 0A is LISTEN
+            if len(fields) > 3 and fields[3] == "0A":
+                try:
+                    ports.add(int(fields[1].rsplit(":", 1)[1], 16))
+                except (IndexError, ValueError):
+                    pass
+    return ports
+
+
+def _check_owntone_ports_free(zone):
+    """Fail fast if another process in the sender namespace holds this zone's OwnTone ports."""
+    subdev = zone.allocated_subdevice
+    wanted = {
+        "HTTP": zone.owntone_port or (OWNTONE_PORT_BASE + subdev * 10),
+        "websocket": OWNTONE_WEBSOCKET_PORT_BASE + subdev * 10,
+        "MPD": OWNTONE_MPD_PORT_BASE + subdev,
+    }
+    busy = _netns_listening_tcp_ports(OWNTONE_SENDER_NS)
+    taken = [f"{label} port {port}" for label, port in wanted.items() if port in busy]
+    if taken:
+        raise RuntimeError(
+            f"OwnTone {', '.join(taken)} already in use in {OWNTONE_SENDER_NS}; "
+            "a stale OwnTone may still be running (scripts/shiri_service.sh cleanup)"
+        )
+
+
 def _iface_ipv4_in_netns(ns, iface):
     result = _netns_exec(ns, ["ip", "-4", "-o", "addr", "show", "dev", iface])
     for line in (result.stdout or "").splitlines():
@@ -381,10 +464,14 @@ def _dhclient_paths(role_key):
     return lease_file, pid_file
 
 
+DHCLIENT_SCRIPT_PATH = "/etc/dhcp/dhclient-script"
+DHCLIENT_SCRIPT_MARKER = "Minimal dhclient hook for Shiri network namespaces."
+
+
 def _namespace_dhclient_script():
     source = os.path.join(SCRIPT_DIR, "dhclient_namespace.sh")
-    target = "/etc/dhcp/dhclient-script"
-    marker = "Minimal dhclient hook for Shiri network namespaces."
+    target = DHCLIENT_SCRIPT_PATH
+    marker = DHCLIENT_SCRIPT_MARKER
     try:
         existing = _read_text(target)
     except OSError:
@@ -401,7 +488,7 @@ def _namespace_dhclient_script():
     return target
 
 
-def _acquire_dhcp(ns, iface, role_key, timeout=20):
+def _acquire_dhcp(ns, iface, role_key, parent_iface, timeout=20):
     lease_file, pid_file = _dhclient_paths(role_key)
     script_file = _namespace_dhclient_script()
     result = _netns_exec(ns, [
@@ -417,18 +504,97 @@ def _acquire_dhcp(ns, iface, role_key, timeout=20):
     ip = _iface_ipv4_in_netns(ns, iface)
     if not ip:
         raise RuntimeError(f"DHCP succeeded but {iface} in {ns} has no IPv4 address")
+    _check_lan_address_conflict(ns, iface, ip, parent_iface)
     _preflight_lan_unicast(ns, iface, ip)
     return ip
 
 
-def _preflight_lan_unicast(ns, iface, ip):
+def _host_ipv4_addrs(iface):
+    result = _run(["ip", "-4", "-o", "addr", "show", "dev", iface])
+    addrs = []
+    for line in (result.stdout or "").splitlines():
+        parts = line.split()
+        if "inet" in parts:
+            addrs.append(parts[parts.index("inet") + 1].split("/", 1)[0])
+    return addrs
+
+
+def _host_ipv4_interfaces(iface):
+    result = _run(["ip", "-4", "-o", "addr", "show", "dev", iface])
+    interfaces = []
+    for line in (result.stdout or "").splitlines():
+        parts = line.split()
+        if "inet" in parts:
+            try:
+                interfaces.append(ipaddress.IPv4Interface(parts[parts.index("inet") + 1]))
+            except ValueError:
+                pass
+    return interfaces
+
+
+def _host_default_gateway(iface):
+    result = _run(["ip", "-4", "route", "show", "default", "dev", iface])
+    for line in (result.stdout or "").splitlines():
+        parts = line.split()
+        if "via" in parts:
+            return parts[parts.index("via") + 1]
+    return ""
+
+
+def _assign_static_address(ns, iface, cidr, gateway, parent_iface):
+    """Configure a fixed address instead of DHCP, refusing one already in use."""
+    address = ipaddress.IPv4Interface(cidr)
+    ip = str(address.ip)
+    host_networks = [item.network for item in _host_ipv4_interfaces(parent_iface)]
+    if host_networks and address.network not in host_networks:
+        raise RuntimeError(
+            f"Static address {cidr} is not in {parent_iface}'s subnet "
+            f"({', '.join(str(network) for network in host_networks)})"
+        )
+    if ip in _host_ipv4_addrs(parent_iface):
+        raise RuntimeError(f"Static address {ip} is the host's own address on {parent_iface}")
+    # Nothing of ours holds the address yet, so any reply means another device does.
+    probe = _run(["ping", "-c", "1", "-W", "1", "-I", parent_iface, ip], timeout=3)
+    if probe.returncode == 0:
+        raise RuntimeError(f"Static address {ip} is already in use on the LAN; pick a free address")
+
+    gateway = gateway or _host_default_gateway(parent_iface)
+    _netns_exec(ns, ["ip", "-4", "addr", "add", str(address), "dev", iface], check=True)
+    if gateway:
+        _netns_exec(ns, ["ip", "-4", "route", "replace", "default", "via", gateway, "dev", iface], check=True)
+    _check_lan_address_conflict(ns, iface, ip, parent_iface)
+    _preflight_lan_unicast(ns, iface, ip)
+    log.info("Assigned static address %s to %s/%s", cidr, ns, iface)
+    return ip
+
+
+def _netns_default_gateway(ns, iface):
     result = _netns_exec(ns, ["ip", "-4", "route", "show", "default", "dev", iface])
-    gateway = ""
     for line in (result.stdout or "").splitlines():
         parts = line.split()
         if "via" in parts:
-            gateway = parts[parts.index("via") + 1]
-            break
+            return parts[parts.index("via") + 1]
+    return ""
+
+
+def _check_lan_address_conflict(ns, iface, ip, parent_iface):
+    """Refuse a macvlan lease that duplicates the host or gateway address."""
+    owners = []
+    if ip in _host_ipv4_addrs(parent_iface):
+        owners.append(f"host interface {parent_iface}")
+    if ip == _netns_default_gateway(ns, iface):
+        owners.append("default gateway")
+    if owners:
+        _netns_exec(ns, ["ip", "-4", "addr", "flush", "dev", iface])
+        raise RuntimeError(
+            f"DHCP handed {ns}/{iface} address {ip}, which is already used by the "
+            f"{' and '.join(owners)}. Fix the router's DHCP pool/reservations before "
+            "starting Shiri."
+        )
+
+
+def _preflight_lan_unicast(ns, iface, ip):
+    gateway = _netns_default_gateway(ns, iface)
     if not gateway:
         log.warning("No default gateway found for %s/%s after DHCP", ns, iface)
         return
@@ -536,6 +702,7 @@ def _ensure_owntone_sender(parent_iface):
                 OWNTONE_SENDER_NS,
                 OWNTONE_SENDER_IFACE,
                 "sender:owntone",
+                parent_iface,
             )
 
             _write_text(_sender_state("netns.txt"), OWNTONE_SENDER_NS)
@@ -639,6 +806,14 @@ def _binary_exists(name):
     return result.returncode == 0 and bool((result.stdout or "").strip())
 
 
+def missing_binaries():
+    """Return the REQUIRED_BINARIES that are not installed."""
+    return [
+        name for name in REQUIRED_BINARIES
+        if not os.path.exists(PREFERRED_BINARIES.get(name, "")) and not shutil.which(name)
+    ]
+
+
 def _binary(name):
     preferred = PREFERRED_BINARIES.get(name)
     if preferred and os.path.exists(preferred):
@@ -656,7 +831,12 @@ def _start_receiver_namespace(zone):
     _teardown_receiver_namespace(zone)
     _ensure_netns(ns)
     _create_macvlan_in_netns(zone.interface, ns, iface, f"receiver:{zone.zone_id}")
-    receiver_ip = _acquire_dhcp(ns, iface, f"receiver:{zone.zone_id}")
+    if zone.config.get("static_ip"):
+        receiver_ip = _assign_static_address(
+            ns, iface, zone.config["static_ip"], zone.config.get("static_gateway"), zone.interface,
+        )
+    else:
+        receiver_ip = _acquire_dhcp(ns, iface, f"receiver:{zone.zone_id}", zone.interface)
 
     _write_text(_state_path(zone.grp_dir, "receiver_netns.txt"), ns)
     _write_text(_state_path(zone.grp_dir, "receiver_iface.txt"), iface)
@@ -783,6 +963,25 @@ def _delete_host_link(iface):
                         (result.stderr or result.stdout or "").strip())
 
 
+def _remove_zone_fifos(grp_dir):
+    """Unlink the zone's FIFOs so a stopped zone leaves no pipes behind.
+    setup_directories recreates them on the next start."""
+    pipes_dir = os.path.join(grp_dir, "pipes")
+    try:
+        names = os.listdir(pipes_dir)
+    except FileNotFoundError:
+        return
+    for name in names:
+        path = os.path.join(pipes_dir, name)
+        try:
+            if stat.S_ISFIFO(os.lstat(path).st_mode):
+                os.remove(path)
+        except FileNotFoundError:
+            pass
+        except OSError as e:
+            log.debug("Could not remove FIFO %s: %s", path, e)
+
+
 def _clear_runtime_state(grp_dir):
     for filename in [
         "mixer.pid",
@@ -910,6 +1109,12 @@ def start_zone_thread(zone, cleanup_fn):
             zone._set_status(Zone.STATUS_ERROR, "No network interface configured")
             return
 
+        missing = missing_binaries()
+        if missing:
+            zone._set_status(Zone.STATUS_ERROR, f"Not installed: {', '.join(missing)}")
+            log.error("Cannot start zone %s; missing programs: %s", zone.zone_id, ", ".join(missing))
+            return
+
         _allocate_resources(zone)
         _generate_configs(zone)
         _start_zone_airplay2_netns(zone)
@@ -932,14 +1137,18 @@ def start_zone_thread(zone, cleanup_fn):
     except Exception as e:
         log.exception("Failed to start zone %s", zone.zone_id)
         zone._set_status(Zone.STATUS_ERROR, str(e))
-        cleanup_fn(zone)
+        try:
+            cleanup_fn(zone)
+        except Exception as cleanup_error:
+            log.error("Cleanup after failed start of %s was incomplete: %s",
+                      zone.zone_id, cleanup_error)
 
 
 def _allocate_resources(zone):
     """Step 1-2: Allocate loopback subdevice and setup directories."""
     from zone import Zone
 
-    subdev = allocate_loopback_subdevice()
+    subdev = allocate_loopback_subdevice(preferred=zone.config.get("loopback_subdevice"))
     if subdev is None:
         zone._set_status(Zone.STATUS_ERROR, "No free loopback subdevices")
         raise RuntimeError("No free loopback subdevices")
@@ -1007,26 +1216,44 @@ def _real_speaker_outputs(outputs, excluded_names):
     return [
         output
         for output in outputs
-        if str(output.get("type") or "") in {"AirPlay 2", "ALSA"}
+        if str(output.get("type") or "") in SPEAKER_OUTPUT_TYPES
         and str(output.get("name") or "") not in excluded
     ]
 
 
 def _launch_host_processes(zone):
-    """Step 6: Start the host mixer that feeds OwnTone's pipe input."""
+    """Step 6: Start the now-playing reader and the host mixer that feeds OwnTone's pipe input."""
+    _start_metadata_reader(zone)
     _start_mixer(zone)
 
 
+def _start_metadata_reader(zone):
+    def notify():
+        if zone.on_status_change:
+            zone.on_status_change(zone)
+
+    reader = MetadataReader(
+        zone.zone_id,
+        os.path.join(zone.grp_dir, "pipes", "shairport.metadata"),
+        on_update=notify,
+    )
+    reader.start()
+    zone.metadata_reader = reader
+
+
 def _restore_speakers(zone):
     """Restore saved speaker selections with retry loop.
     AirPlay speaker discovery via mDNS can take 5-15 seconds."""
     if not zone.owntone_api:
         return
-    if not (zone.config.get("speakers") or zone.config.get("speaker_names")):
-        return
 
-    speaker_names = zone.config.get("speaker_names", [])
+    speaker_names = list(zone.config.get("speaker_names", []))
     speaker_ids = zone.config.get("speakers", [])
+    # Speaker groups add their members by name on top of the zone's own picks.
+    saved = {s.get("name") for s in speaker_names}
+    speaker_names += [{"name": name} for name in zone.group_speaker_names if name not in saved]
+    if not (speaker_ids or speaker_names):
+        return
     saved_names = [s.get("name") for s in speaker_names if s.get("name")]
     
     log.info("Waiting for speakers to appear: %s", saved_names or speaker_ids)
@@ -1099,42 +1326,66 @@ def stop_zone_thread(zone, cleanup_fn):
 
 def cleanup_zone(zone):
     """
-    Zone cleanup sequence:
-    1. Stop host-side audio helpers
-    2. Stop Shairport and OwnTone
-    3. Release loopback subdevice after all users are gone
+    Zone cleanup sequence, upstream to downstream so nothing is left writing
+    into a pipe whose reader is already gone:
+    1. Stop Shairport (the audio source) and its receiver namespace
+    2. Stop the mixer with SIGTERM so it can flush into OwnTone's pipe
+    3. Stop OwnTone (the sender)
+    4. Release loopback subdevice after all users are gone
     """
     log.info("Cleaning up zone %s...", zone.zone_id)
 
     grp_dir = zone.grp_dir
-    # 1. Stop host-side audio helpers.
-    _kill_pid(zone.mixer_pid, f"mixer supervisor ({zone.zone_id})")
-    _kill_pid(_read_pid(_state_path(grp_dir, "mixer.pid")), f"mixer ({zone.zone_id})")
-
-    zone.mixer_pid = None
-
-    # 2. Stop AirPlay receiver and OwnTone sender processes.
-    _terminate_pid(
-        zone.shairport_pid or _read_pid(_state_path(grp_dir, "shairport.pid")),
-        f"shairport-sync ({zone.zone_id})",
-        timeout=3,
-    )
-    zone.shairport_pid = None
+    survivors = []
+    # 1. Stop the AirPlay receiver first so no new audio enters the loopback.
+    shairport_pid = zone.shairport_pid or _read_pid(_state_path(grp_dir, "shairport.pid"))
+    if _terminate_pid(shairport_pid, f"shairport-sync ({zone.zone_id})", timeout=3):
+        zone.shairport_pid = None
+        zone.shairport_proc = None
+    else:
+        survivors.append(f"shairport-sync pid {shairport_pid}")
+        zone.shairport_pid = shairport_pid
+    if zone.metadata_reader:
+        zone.metadata_reader.stop()
+        zone.metadata_reader = None
     _teardown_receiver_namespace(zone)
 
-    _terminate_pid(
-        zone.owntone_pid or _read_pid(_state_path(grp_dir, "owntone.pid")),
-        f"owntone ({zone.zone_id})",
-        timeout=5,
-    )
-    zone.owntone_pid = None
+    # 2. Stop the mixer while OwnTone is still reading its pipe, giving it
+    # time to shut its GStreamer pipeline down cleanly.
+    mixer_stopped = True
+    for pid, label in [
+        (zone.mixer_pid, f"mixer supervisor ({zone.zone_id})"),
+        (_read_pid(_state_path(grp_dir, "mixer.pid")), f"mixer ({zone.zone_id})"),
+    ]:
+        if not _terminate_pid(pid, label, timeout=3):
+            survivors.append(f"{label} pid {pid}")
+            mixer_stopped = False
+    if mixer_stopped:
+        zone.mixer_pid = None
+        zone.mixer_proc = None
+
+    # 3. Stop the OwnTone sender last.
+    owntone_pid = zone.owntone_pid or _read_pid(_state_path(grp_dir, "owntone.pid"))
+    if _terminate_pid(owntone_pid, f"owntone ({zone.zone_id})", timeout=5):
+        zone.owntone_pid = None
+        zone.owntone_proc = None
+    else:
+        survivors.append(f"owntone pid {owntone_pid}")
+        zone.owntone_pid = owntone_pid
     _teardown_owntone_sender()
 
-    # 3. Release loopback subdevice only after all processes that could touch it
+    # Survivors still hold the subdevice, pipes and ports. Keep their pids,
+    # state files and allocation so a later stop can retry instead of
+    # handing them to the next zone.
+    if survivors:
+        raise RuntimeError(f"processes refused to exit: {', '.join(survivors)}")
+
+    # 4. Release loopback subdevice only after all processes that could touch it
     # have been stopped.
     release_loopback_subdevice(zone.allocated_subdevice)
     zone.allocated_subdevice = None
     _clear_runtime_state(grp_dir)
+    _remove_zone_fifos(grp_dir)
 
     # Reset state
     zone.shairport_ip = None
@@ -1142,17 +1393,53 @@ def cleanup_zone(zone):
     zone.shairport_port = None
     zone.owntone_port = None
     zone.tts_webrtc_socket = None
+    zone.muted = False
     zone.owntone_api = None
-
     log.info("Zone %s cleanup complete", zone.zone_id)
 
 
+# A receiver/sender that exits within this window failed to start (bad
+# config, port in use); report its own output instead of a false "running".
+STARTUP_GRACE_SECONDS = 1.5
+STARTUP_LOG_TAIL_LINES = 5
+
+
+def _log_size(path):
+    try:
+        return os.path.getsize(path)
+    except OSError:
+        return 0
+
+
+def _log_tail_since(path, offset, lines=STARTUP_LOG_TAIL_LINES):
+    try:
+        with open(path, "rb") as f:
+            f.seek(offset)
+            data = f.read()
+    except OSError:
+        return ""
+    tail = [line.strip() for line in data.decode(errors="replace").splitlines() if line.strip()]
+    return " | ".join(tail[-lines:])
+
+
+def _ensure_started(proc, label, log_path, log_offset, started_at):
+    """Raise with the tail of this run's log if proc exits within the grace period."""
+    deadline = started_at + STARTUP_GRACE_SECONDS
+    while proc.poll() is None and time.monotonic() < deadline:
+        time.sleep(0.1)
+    if proc.poll() is None:
+        return
+    detail = _log_tail_since(log_path, log_offset) or f"no output, see {log_path}"
+    raise RuntimeError(f"{label} exited during startup (code {proc.returncode}): {detail}")
+
+
 def _start_zone_airplay2_netns(zone):
     """Start Shairport and OwnTone in their AirPlay 2 timing namespaces."""
     grp_dir = zone.grp_dir
     subdev = zone.allocated_subdevice
     owntone_port = zone.owntone_port or (OWNTONE_PORT_BASE + subdev * 10)
     api_ip, bridge_ip = _ensure_owntone_sender(zone.interface)
+    _check_owntone_ports_free(zone)
     receiver_ns, _, shairport_ip = _start_receiver_namespace(zone)
 
     zone.owntone_ip = api_ip
@@ -1165,32 +1452,46 @@ def _start_zone_airplay2_netns(zone):
     _write_text(_state_path(grp_dir, "owntone_netns.txt"), OWNTONE_SENDER_NS)
     _write_text(_state_path(grp_dir, "shairport_ip.txt"), shairport_ip)
 
+    shairport_log = os.path.join(grp_dir, "logs", "shairport.log")
+    shairport_log_offset = _log_size(shairport_log)
+    shairport_started = time.monotonic()
+    # Extra arguments were checked against the allowlist when saved and
+    # contain no "--" backend options, so they simply go last.
     shairport_proc = _popen_isolated(
         _receiver_run_dir(zone),
         receiver_ns,
         ["chrt", "-f", "50", _binary("shairport-sync"),
          "-c", os.path.join(grp_dir, "config", "shairport-sync.conf"),
-         "--statistics"],
-        os.path.join(grp_dir, "logs", "shairport.log"),
+         "--statistics", *zone.config.get("shairport_args", [])],
+        shairport_log,
     )
+    zone.shairport_proc = shairport_proc
     zone.shairport_pid = shairport_proc.pid
     _write_text(_state_path(grp_dir, "shairport.pid"), shairport_proc.pid)
     log.info("Started shairport-sync for %s in %s at %s (pid %d)",
              zone.zone_id, receiver_ns, shairport_ip, shairport_proc.pid)
 
+    owntone_log = os.path.join(grp_dir, "logs", "owntone_wrapper.log")
+    owntone_log_offset = _log_size(owntone_log)
+    owntone_started = time.monotonic()
     owntone_proc = _popen_isolated(
         _sender_run_dir(),
         OWNTONE_SENDER_NS,
         ["chrt", "-f", "50", _binary("owntone"), "-f",
          "-c", os.path.join(grp_dir, "config", "owntone.conf"),
          "--mdns-no-rsp", "--mdns-no-daap", "--mdns-no-web", "--mdns-no-cname"],
-        os.path.join(grp_dir, "logs", "owntone_wrapper.log"),
+        owntone_log,
     )
+    zone.owntone_proc = owntone_proc
     zone.owntone_pid = owntone_proc.pid
     _write_text(_state_path(grp_dir, "owntone.pid"), owntone_proc.pid)
     log.info("Started OwnTone for %s in %s port %d api %s bridge %s (pid %d)",
              zone.zone_id, OWNTONE_SENDER_NS, owntone_port, api_ip, bridge_ip, owntone_proc.pid)
 
+    # Both grace periods run concurrently.
+    _ensure_started(shairport_proc, "shairport-sync", shairport_log, shairport_log_offset, shairport_started)
+    _ensure_started(owntone_proc, "OwnTone", owntone_log, owntone_log_offset, owntone_started)
+
 
 def _wait_for_owntone(zone, timeout=60):
     """Wait for the generated OwnTone API endpoint, then poll the API."""
@@ -1234,7 +1535,7 @@ def _wait_for_owntone(zone, timeout=60):
     return False
 
 
-def _start_mixer(zone):
+def _start_mixer(zone, append_log=False):
     """
     Start the host audio mixer.
     It captures ALSA loopback, overlays live streamed TTS, and writes OwnTone's audio.pipe.
@@ -1244,11 +1545,25 @@ def _start_mixer(zone):
     script_path = generate_mixer_supervisor(zone)
 
     log_path = os.path.join(zone.grp_dir, "logs", "mixer.log")
-    # Don't use context manager - file must stay open for subprocess lifetime
-    log_file = open(log_path, "w")
-    proc = subprocess.Popen(
-        ["bash", script_path],
-        stdout=log_file, stderr=subprocess.STDOUT
-    )
+    # The child inherits its own copy of the log fd; close ours so repeated
+    # mixer restarts don't leak one per restart.
+    with open(log_path, "a" if append_log else "w") as log_file:
+        proc = subprocess.Popen(
+            ["bash", script_path],
+            stdout=log_file, stderr=subprocess.STDOUT
+        )
+    zone.mixer_proc = proc
     zone.mixer_pid = proc.pid
     log.info("Started mixer supervisor for %s (pid %d)", zone.zone_id, proc.pid)
+
+
+def restart_mixer(zone):
+    """Replace a dead mixer for a running zone.
+
+    Shairport and OwnTone keep running; the new mixer reopens the loopback
+    capture and OwnTone's audio.pipe, so only the gap while it starts is lost.
+    """
+    stale_pid = _read_pid(_state_path(zone.grp_dir, "mixer.pid"))
+    if not _kill_pid(stale_pid, f"stale mixer ({zone.zone_id})"):
+        raise RuntimeError(f"previous mixer pid {stale_pid} refused to exit")
+    _start_mixer(zone, append_log=True)